@@ -1,14 +1,18 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 
 	"eth-explorer-api/internal/config"
+	"eth-explorer-api/internal/decoder"
+	"eth-explorer-api/internal/explorer"
 	"eth-explorer-api/internal/handlers"
 	"eth-explorer-api/internal/services"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
 )
 
@@ -20,11 +24,38 @@ func main() {
 	fmt.Printf("Config loaded - Port: %s, ETH_NODE_URL: %s\n", cfg.Port, cfg.EthNodeURL)
 
 	fmt.Println("Initializing Ethereum service...")
-	ethService, err := services.NewEthService(cfg.EthNodeURL, cfg.EtherscanAPIKey)
+	explorerClient := explorer.NewClient(cfg.EtherscanAPIURL, cfg.EtherscanAPIKey)
+	ethService, err := services.NewEthService(cfg.EthNodeURL, explorerClient)
 	if err != nil {
 		fmt.Printf("ERROR: Failed to initialize Ethereum service: %v\n", err)
 		log.Fatal("Failed to initialize Ethereum service:", err)
 	}
+	ethService.SetStrictChecksum(cfg.StrictAddressChecksum)
+	ethService.SetLogChunkSize(cfg.LogChunkSize)
+	ethService.SetFeeHistoryBlocks(cfg.FeeHistoryBlocks)
+	if cfg.ABIDir != "" {
+		registry, err := decoder.LoadRegistry(cfg.ABIDir)
+		if err != nil {
+			log.Fatal("Failed to load ABI registry:", err)
+		}
+		ethService.SetABIRegistry(registry)
+	}
+	if cfg.Multicall3Address != "" {
+		if !common.IsHexAddress(cfg.Multicall3Address) {
+			log.Fatalf("Invalid MULTICALL3_ADDRESS %q", cfg.Multicall3Address)
+		}
+		ethService.SetMulticallAddress(common.HexToAddress(cfg.Multicall3Address))
+	}
+	if cfg.TokenListFile != "" {
+		tokenList, err := services.LoadTokenList(cfg.TokenListFile)
+		if err != nil {
+			log.Fatal("Failed to load token list:", err)
+		}
+		ethService.SetTokenList(tokenList)
+	}
+	if cfg.GasHistoryBlocks > 0 {
+		ethService.StartGasHistory(context.Background(), cfg.GasHistoryBlocks, cfg.GasHistoryPollInterval)
+	}
 	fmt.Println("Ethereum service initialized successfully!")
 
 	fmt.Println("Initializing handlers...")
@@ -48,17 +79,31 @@ func main() {
 	api := router.Group("/api/v1")
 	{
 		// Ethereum endpoints
-		api.GET("/eth/block/:number", ethHandler.GetBlock)
-		api.GET("/eth/transaction/:hash", ethHandler.GetTransaction)
-		api.GET("/eth/balance/:address", ethHandler.GetBalance)
-		api.GET("/eth/latest-block", ethHandler.GetLatestBlock)
-		api.GET("/eth/gas-price", ethHandler.GetGasPrice)
-		api.GET("/eth/history/:address", ethHandler.GetTransactionHistory)
-		api.GET("/eth/token-balance/:address/:tokenAddress", ethHandler.GetTokenBalance)
-		api.GET("/eth/token-transfers/:address", ethHandler.GetTokenTransfers)
-		api.GET("/eth/contract-abi/:address", ethHandler.GetContractABI)
-		api.GET("/eth/contract-source/:address", ethHandler.GetContractSource)
-		api.GET("/eth/event-logs/:address", ethHandler.GetEventLogs)
+		api.GET("/eth/block/:number", handlers.Timeout(cfg.TimeoutFor("block")), ethHandler.GetBlock)
+		api.GET("/eth/block/:number/withdrawals", handlers.Timeout(cfg.TimeoutFor("block")), ethHandler.GetBlockWithdrawals)
+		api.GET("/eth/block/hash/:hash", handlers.Timeout(cfg.TimeoutFor("block")), ethHandler.GetBlockByHash)
+		api.GET("/eth/transaction/:hash", handlers.Timeout(cfg.TimeoutFor("transaction")), ethHandler.GetTransaction)
+		api.GET("/eth/transaction/:hash/receipt", handlers.Timeout(cfg.TimeoutFor("transaction")), ethHandler.GetTransactionReceipt)
+		api.GET("/eth/transaction/:hash/status", handlers.Timeout(cfg.TimeoutFor("transaction")), ethHandler.GetTransactionStatus)
+		api.GET("/eth/transaction/:hash/trace", handlers.Timeout(cfg.TimeoutFor("transaction")), ethHandler.TraceTransaction)
+		api.POST("/eth/transaction/send", handlers.Timeout(cfg.TimeoutFor("transaction")), ethHandler.SendTransaction)
+		api.GET("/eth/balance/:address", handlers.Timeout(cfg.TimeoutFor("balance")), ethHandler.GetBalance)
+		api.GET("/eth/latest-block", handlers.Timeout(cfg.TimeoutFor("latest-block")), ethHandler.GetLatestBlock)
+		api.GET("/eth/gas-price", handlers.Timeout(cfg.TimeoutFor("gas-price")), ethHandler.GetGasPrice)
+		api.GET("/eth/gas/fees", handlers.Timeout(cfg.TimeoutFor("gas")), ethHandler.GetGasFees)
+		api.GET("/eth/gas/history", handlers.Timeout(cfg.TimeoutFor("gas")), ethHandler.GetGasHistory)
+		api.GET("/eth/history/:address", handlers.Timeout(cfg.TimeoutFor("history")), ethHandler.GetTransactionHistory)
+		api.GET("/eth/token-balance/:address/:tokenAddress", handlers.Timeout(cfg.TimeoutFor("token-balance")), ethHandler.GetTokenBalance)
+		api.GET("/eth/token/:address", handlers.Timeout(cfg.TimeoutFor("token")), ethHandler.GetTokenMetadata)
+		api.GET("/eth/portfolio/:address", handlers.Timeout(cfg.TimeoutFor("portfolio")), ethHandler.GetPortfolio)
+		api.GET("/eth/nft/:contract/:tokenId", handlers.Timeout(cfg.TimeoutFor("nft")), ethHandler.GetNFT)
+		api.GET("/eth/nft-transfers/:address", handlers.Timeout(cfg.TimeoutFor("nft-transfers")), ethHandler.GetNFTTransfers)
+		api.GET("/eth/token-transfers/:address", handlers.Timeout(cfg.TimeoutFor("token-transfers")), ethHandler.GetTokenTransfers)
+		api.GET("/eth/contract-abi/:address", handlers.Timeout(cfg.TimeoutFor("contract-abi")), ethHandler.GetContractABI)
+		api.GET("/eth/contract-source/:address", handlers.Timeout(cfg.TimeoutFor("contract-source")), ethHandler.GetContractSource)
+		api.GET("/eth/event-logs/:address", handlers.Timeout(cfg.TimeoutFor("event-logs")), ethHandler.GetEventLogs)
+		api.POST("/eth/call", handlers.Timeout(cfg.TimeoutFor("call")), ethHandler.Call)
+		api.POST("/eth/simulate", handlers.Timeout(cfg.TimeoutFor("simulate")), ethHandler.Simulate)
 
 		// Health check
 		api.GET("/health", func(c *gin.Context) {
@@ -3,6 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -11,6 +14,39 @@ type Config struct {
 	Port            string
 	EthNodeURL      string
 	EtherscanAPIKey string
+	EtherscanAPIURL string
+
+	// ABIDir holds <address>.json contract ABIs used to decode calldata.
+	ABIDir string
+
+	// StrictAddressChecksum rejects addresses that are not EIP-55 checksummed.
+	StrictAddressChecksum bool
+
+	// RequestTimeout bounds every API request unless RouteTimeouts has an
+	// entry for the route.
+	RequestTimeout time.Duration
+	RouteTimeouts  map[string]time.Duration
+
+	// LogChunkSize is the number of blocks requested per eth_getLogs call.
+	LogChunkSize uint64
+
+	// Multicall3Address overrides the address of the Multicall3 contract.
+	Multicall3Address string
+
+	// TokenListFile is a token list whose tokens a portfolio covers by
+	// default.
+	TokenListFile string
+
+	// FeeHistoryBlocks is the number of recent blocks fee estimates are
+	// based on.
+	FeeHistoryBlocks uint64
+
+	// GasHistoryBlocks is the number of recent blocks the gas history
+	// holds. It is polled for new blocks every GasHistoryPollInterval when
+	// the node connection does not support subscriptions. Zero disables
+	// the gas history.
+	GasHistoryBlocks       uint64
+	GasHistoryPollInterval time.Duration
 }
 
 func Load() *Config {
@@ -21,15 +57,121 @@ func Load() *Config {
 	}
 
 	return &Config{
-		Port:            getEnv("PORT", "8080"),
-		EthNodeURL:      getEnv("ETH_NODE_URL", ""),
-		EtherscanAPIKey: getEnv("ETHERSCAN_API_KEY", ""),
+		Port:                   getEnv("PORT", "8080"),
+		EthNodeURL:             getEnv("ETH_NODE_URL", ""),
+		EtherscanAPIKey:        getEnv("ETHERSCAN_API_KEY", ""),
+		EtherscanAPIURL:        getEnv("ETHERSCAN_API_URL", "https://api.etherscan.io/api"),
+		ABIDir:                 getEnv("ABI_DIR", ""),
+		StrictAddressChecksum:  getBoolEnv("STRICT_ADDRESS_CHECKSUM", false),
+		RequestTimeout:         getDurationEnv("REQUEST_TIMEOUT", 10*time.Second),
+		RouteTimeouts:          parseRouteTimeouts(getEnv("ROUTE_TIMEOUTS", "event-logs=30s,token-transfers=30s,nft-transfers=30s")),
+		LogChunkSize:           getUintEnv("LOG_CHUNK_SIZE", 2000),
+		Multicall3Address:      getEnv("MULTICALL3_ADDRESS", ""),
+		TokenListFile:          getEnv("TOKEN_LIST_FILE", ""),
+		FeeHistoryBlocks:       getUintEnv("FEE_HISTORY_BLOCKS", 20),
+		GasHistoryBlocks:       getUintEnvAllowZero("GAS_HISTORY_BLOCKS", 1024),
+		GasHistoryPollInterval: getPositiveDurationEnv("GAS_HISTORY_POLL_INTERVAL", 4*time.Second),
 	}
 }
 
+// TimeoutFor returns the request timeout for the named route.
+func (c *Config) TimeoutFor(route string) time.Duration {
+	if timeout, ok := c.RouteTimeouts[route]; ok {
+		return timeout
+	}
+	return c.RequestTimeout
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+func getBoolEnv(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid %s %q, using %t", key, value, defaultValue)
+		return defaultValue
+	}
+	return b
+}
+
+func getUintEnv(key string, defaultValue uint64) uint64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || n == 0 {
+		log.Printf("Invalid %s %q, using %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return n
+}
+
+// getUintEnvAllowZero is getUintEnv for settings where zero turns a feature
+// off.
+func getUintEnvAllowZero(key string, defaultValue uint64) uint64 {
+	if os.Getenv(key) == "0" {
+		return 0
+	}
+	return getUintEnv(key, defaultValue)
+}
+
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid %s %q, using %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return d
+}
+
+// getPositiveDurationEnv is getDurationEnv for durations that must be
+// positive, such as ticker intervals.
+func getPositiveDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	d := getDurationEnv(key, defaultValue)
+	if d <= 0 {
+		log.Printf("Invalid %s %q, using %s", key, os.Getenv(key), defaultValue)
+		return defaultValue
+	}
+	return d
+}
+
+// parseRouteTimeouts parses a comma-separated list of route=duration pairs,
+// e.g. "event-logs=30s,history=15s".
+func parseRouteTimeouts(value string) map[string]time.Duration {
+	timeouts := make(map[string]time.Duration)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, raw, ok := strings.Cut(entry, "=")
+		if !ok {
+			log.Printf("Ignoring route timeout %q: expected route=duration", entry)
+			continue
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil {
+			log.Printf("Ignoring route timeout %q: %v", entry, err)
+			continue
+		}
+		timeouts[strings.TrimSpace(route)] = d
+	}
+	return timeouts
+}
@@ -0,0 +1,306 @@
+// Package decoder decodes ABI-encoded calldata and event logs into typed,
+// JSON-friendly values.
+package decoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"eth-explorer-api/internal/models"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Registry holds contract ABIs keyed by address. It is safe for concurrent
+// use.
+type Registry struct {
+	mu   sync.RWMutex
+	abis map[common.Address]*abi.ABI
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		abis: make(map[common.Address]*abi.ABI),
+	}
+}
+
+// LoadRegistry loads every <address>.json file in dir. A file holds either
+// a bare ABI array or a build artifact with an "abi" field.
+func LoadRegistry(dir string) (*Registry, error) {
+	r := NewRegistry()
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".json")
+		if !common.IsHexAddress(name) {
+			return nil, fmt.Errorf("%s: file name is not a contract address", path)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		contractABI, err := ParseABI(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		r.Add(common.HexToAddress(name), contractABI)
+	}
+
+	return r, nil
+}
+
+// ParseABI parses a JSON ABI, accepting either a bare ABI array or a build
+// artifact with an "abi" field.
+func ParseABI(data []byte) (*abi.ABI, error) {
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("{")) {
+		var artifact struct {
+			ABI json.RawMessage `json:"abi"`
+		}
+		if err := json.Unmarshal(data, &artifact); err != nil {
+			return nil, err
+		}
+		data = artifact.ABI
+	}
+
+	contractABI, err := abi.JSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return &contractABI, nil
+}
+
+// Get returns the ABI registered for address.
+func (r *Registry) Get(address common.Address) (*abi.ABI, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	contractABI, ok := r.abis[address]
+	return contractABI, ok
+}
+
+// Add registers the ABI of the contract at address.
+func (r *Registry) Add(address common.Address, contractABI *abi.ABI) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.abis[address] = contractABI
+}
+
+// DecodeCall decodes calldata against method, whose selector must match the
+// first four bytes of data.
+func DecodeCall(method *abi.Method, data []byte) (*models.DecodedInput, error) {
+	if len(data) < 4 || !bytes.Equal(data[:4], method.ID) {
+		return nil, fmt.Errorf("calldata does not start with the selector of %s", method.Sig)
+	}
+
+	args, err := DecodeArguments(method.Inputs, data[4:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.DecodedInput{
+		Selector:  hexutil.Encode(method.ID),
+		Method:    method.RawName,
+		Signature: method.Sig,
+		Arguments: args,
+	}, nil
+}
+
+// DecodeCallBySignature decodes calldata using the embedded table of
+// well-known functions. Only candidates that re-encode to exactly the input
+// are accepted, which weeds out selector collisions.
+func DecodeCallBySignature(data []byte) (*models.DecodedInput, error) {
+	for _, method := range LookupFunctions(data) {
+		values, err := method.Inputs.Unpack(data[4:])
+		if err != nil {
+			continue
+		}
+		packed, err := method.Inputs.Pack(values...)
+		if err != nil || !bytes.Equal(packed, data[4:]) {
+			continue
+		}
+		return DecodeCall(&method, data)
+	}
+
+	return nil, fmt.Errorf("unknown selector %s", hexutil.Encode(data[:min(len(data), 4)]))
+}
+
+// DecodeLog decodes the topics and data of a log emitted by event. The
+// number of indexed topics must match the event. Indexed parameters of
+// dynamic types are stored as their keccak256 hash and rendered as such.
+func DecodeLog(event *abi.Event, topics []common.Hash, data []byte) ([]models.DecodedArgument, error) {
+	indexedTopics := topics
+	if !event.Anonymous {
+		if len(topics) == 0 || topics[0] != event.ID {
+			return nil, fmt.Errorf("log topic does not match %s", event.Sig)
+		}
+		indexedTopics = topics[1:]
+	}
+
+	var indexed abi.Arguments
+	for _, arg := range event.Inputs {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+	if len(indexed) != len(indexedTopics) {
+		return nil, fmt.Errorf("%s has %d indexed parameters, log has %d topics", event.Sig, len(indexed), len(indexedTopics))
+	}
+
+	values, err := event.Inputs.NonIndexed().Unpack(data)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded := make([]models.DecodedArgument, len(event.Inputs))
+	topicIndex, valueIndex := 0, 0
+	for i, arg := range event.Inputs {
+		decoded[i] = models.DecodedArgument{
+			Name:    arg.Name,
+			Type:    arg.Type.String(),
+			Indexed: arg.Indexed,
+		}
+
+		if !arg.Indexed {
+			decoded[i].Value = RenderValue(arg.Type, values[valueIndex])
+			valueIndex++
+			continue
+		}
+
+		topic := indexedTopics[topicIndex]
+		topicIndex++
+		if isHashedTopic(arg.Type) {
+			decoded[i].Value = topic.Hex()
+			continue
+		}
+		value, err := abi.Arguments{{Type: arg.Type}}.Unpack(topic[:])
+		if err != nil {
+			return nil, fmt.Errorf("topic %s: %w", arg.Name, err)
+		}
+		decoded[i].Value = RenderValue(arg.Type, value[0])
+	}
+
+	return decoded, nil
+}
+
+// DecodeLogBySignature decodes a log using the embedded table of well-known
+// events. Only candidates that match the topic count and re-encode to
+// exactly the log data are accepted.
+func DecodeLogBySignature(topics []common.Hash, data []byte) (*abi.Event, []models.DecodedArgument, error) {
+	if len(topics) == 0 {
+		return nil, nil, fmt.Errorf("log has no topics")
+	}
+
+	for _, event := range LookupEvents(topics[0]) {
+		nonIndexed := event.Inputs.NonIndexed()
+		values, err := nonIndexed.Unpack(data)
+		if err != nil {
+			continue
+		}
+		packed, err := nonIndexed.Pack(values...)
+		if err != nil || !bytes.Equal(packed, data) {
+			continue
+		}
+
+		args, err := DecodeLog(&event, topics, data)
+		if err != nil {
+			continue
+		}
+		return &event, args, nil
+	}
+
+	return nil, nil, fmt.Errorf("unknown event topic %s", topics[0].Hex())
+}
+
+// isHashedTopic reports whether indexed values of type t are stored as
+// their keccak256 hash rather than their value.
+func isHashedTopic(t abi.Type) bool {
+	switch t.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+		return true
+	}
+	return false
+}
+
+// DecodeArguments unpacks data against args and renders each value.
+func DecodeArguments(args abi.Arguments, data []byte) ([]models.DecodedArgument, error) {
+	values, err := args.Unpack(data)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded := make([]models.DecodedArgument, len(args))
+	for i, arg := range args {
+		decoded[i] = models.DecodedArgument{
+			Name:  arg.Name,
+			Type:  arg.Type.String(),
+			Value: RenderValue(arg.Type, values[i]),
+		}
+	}
+	return decoded, nil
+}
+
+// RenderValue converts an unpacked ABI value to a JSON-friendly form:
+// integers become decimal strings, byte strings and addresses hex strings,
+// arrays lists, and tuples lists of named, typed fields.
+func RenderValue(t abi.Type, value interface{}) interface{} {
+	return renderValue(t, reflect.ValueOf(value))
+}
+
+func renderValue(t abi.Type, v reflect.Value) interface{} {
+	for v.Kind() == reflect.Ptr && t.T != abi.IntTy && t.T != abi.UintTy {
+		v = v.Elem()
+	}
+
+	switch t.T {
+	case abi.TupleTy:
+		fields := make([]models.DecodedArgument, len(t.TupleElems))
+		for i, elem := range t.TupleElems {
+			fields[i] = models.DecodedArgument{
+				Name:  t.TupleRawNames[i],
+				Type:  elem.String(),
+				Value: renderValue(*elem, v.Field(i)),
+			}
+		}
+		return fields
+	case abi.SliceTy, abi.ArrayTy:
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = renderValue(*t.Elem, v.Index(i))
+		}
+		return items
+	case abi.IntTy, abi.UintTy:
+		if n, ok := v.Interface().(*big.Int); ok {
+			return n.String()
+		}
+		return fmt.Sprint(v.Interface())
+	case abi.AddressTy:
+		return v.Interface().(common.Address).Hex()
+	case abi.BoolTy:
+		return v.Bool()
+	case abi.StringTy:
+		return v.String()
+	case abi.BytesTy:
+		return hexutil.Encode(v.Bytes())
+	case abi.FixedBytesTy, abi.FunctionTy:
+		b := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(b), v)
+		return hexutil.Encode(b)
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
@@ -0,0 +1,335 @@
+package decoder
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"eth-explorer-api/internal/models"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	alice = common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	bob   = common.HexToAddress("0x00000000000000000000000000000000000000b0")
+)
+
+func TestParseSignature(t *testing.T) {
+	name, args, err := ParseSignature("Transfer(address indexed from, address indexed to, uint256 value)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "Transfer" || len(args) != 3 {
+		t.Fatalf("name = %s, args = %d", name, len(args))
+	}
+	if !args[0].Indexed || args[0].Name != "from" || args[2].Indexed || args[2].Type.String() != "uint256" {
+		t.Errorf("args = %+v", args)
+	}
+
+	_, args, err = ParseSignature("aggregate3((address,bool,bytes)[])")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := args[0].Type.String(); got != "(address,bool,bytes)[]" {
+		t.Errorf("type = %s", got)
+	}
+
+	for _, sig := range []string{"", "transfer", "transfer(address", "f((uint256)", "f(foo)", "f(uint256 a b)"} {
+		if _, _, err := ParseSignature(sig); err == nil {
+			t.Errorf("ParseSignature(%q) succeeded, want error", sig)
+		}
+	}
+}
+
+func TestDecodeCallBySignature(t *testing.T) {
+	name, args, _ := ParseSignature("transfer(address,uint256)")
+	method := abi.NewMethod(name, name, abi.Function, "", false, false, args, nil)
+	packed, err := method.Inputs.Pack(bob, big.NewInt(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeCallBySignature(append(method.ID, packed...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Method != "transfer" || decoded.Signature != "transfer(address,uint256)" || decoded.Selector != "0xa9059cbb" {
+		t.Errorf("decoded = %+v", decoded)
+	}
+	if decoded.Arguments[0].Value != bob.Hex() || decoded.Arguments[1].Value != "1000" {
+		t.Errorf("arguments = %+v", decoded.Arguments)
+	}
+
+	// Truncated calldata must not match.
+	if _, err := DecodeCallBySignature(append(method.ID, packed[:32]...)); err == nil {
+		t.Error("decoded truncated calldata")
+	}
+	if _, err := DecodeCallBySignature([]byte{0xde, 0xad, 0xbe, 0xef}); err == nil {
+		t.Error("decoded unknown selector")
+	}
+}
+
+func TestDecodeNestedTuples(t *testing.T) {
+	const abiJSON = `[{"type":"function","name":"aggregate3","inputs":[{"name":"calls","type":"tuple[]","components":[
+		{"name":"target","type":"address"},
+		{"name":"allowFailure","type":"bool"},
+		{"name":"callData","type":"bytes"}]}],"outputs":[]}]`
+
+	contractABI, err := ParseABI([]byte(abiJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	method := contractABI.Methods["aggregate3"]
+
+	type call struct {
+		Target       common.Address
+		AllowFailure bool
+		CallData     []byte
+	}
+	data, err := contractABI.Pack("aggregate3", []call{
+		{Target: alice, AllowFailure: true, CallData: []byte{0x01, 0x02}},
+		{Target: bob, CallData: nil},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeCall(&method, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := json.Marshal(decoded.Arguments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"name":"calls","type":"(address,bool,bytes)[]","value":[` +
+		`[{"name":"target","type":"address","value":"` + alice.Hex() + `"},{"name":"allowFailure","type":"bool","value":true},{"name":"callData","type":"bytes","value":"0x0102"}],` +
+		`[{"name":"target","type":"address","value":"` + bob.Hex() + `"},{"name":"allowFailure","type":"bool","value":false},{"name":"callData","type":"bytes","value":"0x"}]]}]`
+	if string(got) != want {
+		t.Errorf("arguments =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDecodeLogBySignature(t *testing.T) {
+	topic0 := crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	from := common.BytesToHash(alice.Bytes())
+	to := common.BytesToHash(bob.Bytes())
+	amount := common.BigToHash(big.NewInt(1000))
+
+	// ERC-20: the amount is in the data.
+	event, params, err := DecodeLogBySignature([]common.Hash{topic0, from, to}, amount.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Sig != "Transfer(address,address,uint256)" || len(params) != 3 {
+		t.Fatalf("event = %s, params = %+v", event.Sig, params)
+	}
+	if params[0].Value != alice.Hex() || !params[0].Indexed || params[2].Name != "value" || params[2].Value != "1000" || params[2].Indexed {
+		t.Errorf("params = %+v", params)
+	}
+
+	// ERC-721: the token ID is the third topic.
+	_, params, err = DecodeLogBySignature([]common.Hash{topic0, from, to, amount}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params[2].Name != "tokenId" || params[2].Value != "1000" || !params[2].Indexed {
+		t.Errorf("params = %+v", params)
+	}
+
+	if _, _, err := DecodeLogBySignature([]common.Hash{topic0, from}, amount.Bytes()); err == nil {
+		t.Error("decoded a log with missing topics")
+	}
+	if _, _, err := DecodeLogBySignature([]common.Hash{crypto.Keccak256Hash([]byte("Unknown()"))}, nil); err == nil {
+		t.Error("decoded unknown topic")
+	}
+}
+
+func TestDecodeLogHashedTopic(t *testing.T) {
+	name, args, _ := ParseSignature("Registered(string indexed name, address owner)")
+	event := abi.NewEvent(name, name, false, args)
+	nameHash := crypto.Keccak256Hash([]byte("alice"))
+
+	params, err := DecodeLog(&event, []common.Hash{event.ID, nameHash}, common.BytesToHash(alice.Bytes()).Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params[0].Value != nameHash.Hex() || params[1].Value != alice.Hex() {
+		t.Errorf("params = %+v", params)
+	}
+}
+
+func TestEmbeddedFunctions(t *testing.T) {
+	for _, sig := range signatureLines(functionsFile) {
+		if _, _, err := ParseSignature(sig); err != nil {
+			t.Errorf("%s: %v", sig, err)
+		}
+	}
+}
+
+func TestEmbeddedEvents(t *testing.T) {
+	for _, sig := range signatureLines(eventsFile) {
+		if _, _, err := ParseSignature(sig); err != nil {
+			t.Errorf("%s: %v", sig, err)
+		}
+	}
+}
+
+func TestLoadRegistry(t *testing.T) {
+	dir := t.TempDir()
+	artifact := `{"contractName":"Token","abi":[{"type":"function","name":"balanceOf","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]}]}`
+	if err := os.WriteFile(filepath.Join(dir, strings.ToLower(alice.Hex())+".json"), []byte(artifact), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	registry, err := LoadRegistry(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contractABI, ok := registry.Get(alice)
+	if !ok {
+		t.Fatal("ABI not registered")
+	}
+	if _, ok := contractABI.Methods["balanceOf"]; !ok {
+		t.Errorf("methods = %v", contractABI.Methods)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "token.json"), []byte("[]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadRegistry(dir); err == nil {
+		t.Error("loaded a file not named after an address")
+	}
+}
+
+func TestParseFunction(t *testing.T) {
+	for _, sig := range []string{
+		"balanceOf(address)(uint256)",
+		"function balanceOf(address owner) external view returns (uint256 balance)",
+		"balanceOf(address) view returns(uint256)",
+	} {
+		method, err := ParseFunction(sig)
+		if err != nil {
+			t.Fatalf("ParseFunction(%q): %v", sig, err)
+		}
+		if method.Sig != "balanceOf(address)" || len(method.Outputs) != 1 || method.Outputs[0].Type.String() != "uint256" {
+			t.Errorf("ParseFunction(%q) = %s with outputs %v", sig, method.Sig, method.Outputs)
+		}
+	}
+
+	method, err := ParseFunction("function setName(string memory name) payable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if method.Sig != "setName(string)" || !method.Payable || len(method.Outputs) != 0 {
+		t.Errorf("method = %+v", method)
+	}
+
+	for _, sig := range []string{"balanceOf", "balanceOf(address) returns", "balanceOf(address) internal", "balanceOf(address)(uint256"} {
+		if _, err := ParseFunction(sig); err == nil {
+			t.Errorf("ParseFunction(%q) succeeded, want error", sig)
+		}
+	}
+}
+
+func TestEncodeArguments(t *testing.T) {
+	method, err := ParseFunction("f(uint8 small, int256 delta, address to, bytes4 tag, (address target, bool ok)[] calls, string note)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := []json.RawMessage{
+		json.RawMessage(`255`),
+		json.RawMessage(`"-0x10"`),
+		json.RawMessage(`"` + bob.Hex() + `"`),
+		json.RawMessage(`"0xa9059c"`),
+		json.RawMessage(`[{"target":"` + alice.Hex() + `","ok":true},["` + bob.Hex() + `",false]]`),
+		json.RawMessage(`"hi"`),
+	}
+	packed, err := EncodeArguments(method.Inputs, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeArguments(method.Inputs, packed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal([]interface{}{decoded[0].Value, decoded[1].Value, decoded[2].Value, decoded[3].Value, decoded[5].Value})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `["255","-16","` + bob.Hex() + `","0xa9059c00","hi"]`; string(got) != want {
+		t.Errorf("decoded = %s, want %s", got, want)
+	}
+	calls := decoded[4].Value.([]interface{})
+	if len(calls) != 2 || calls[1].([]models.DecodedArgument)[0].Value != bob.Hex() {
+		t.Errorf("calls = %+v", calls)
+	}
+
+	for name, bad := range map[string]string{
+		"overflow":  `256`,
+		"negative":  `-1`,
+		"not a int": `"ten"`,
+		"float":     `1.5`,
+	} {
+		if _, err := EncodeArguments(method.Inputs[:1], []json.RawMessage{json.RawMessage(bad)}); err == nil {
+			t.Errorf("%s: encoded %s as uint8", name, bad)
+		}
+	}
+	if _, err := EncodeArguments(method.Inputs, args[:2]); err == nil {
+		t.Error("encoded too few arguments")
+	}
+}
+
+func TestDecodeRevert(t *testing.T) {
+	reason, _ := abi.Arguments{{Type: mustType("string")}}.Pack("not enough")
+	panicCode, _ := abi.Arguments{{Type: mustType("uint256")}}.Pack(big.NewInt(0x11))
+
+	contractABI, err := ParseABI([]byte(`[{"type":"error","name":"InsufficientBalance","inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}]}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom := contractABI.Errors["InsufficientBalance"]
+	customArgs, _ := custom.Inputs.Pack(big.NewInt(1), big.NewInt(2))
+
+	tests := []struct {
+		name   string
+		data   []byte
+		kind   string
+		reason string
+	}{
+		{"error", append([]byte{0x08, 0xc3, 0x79, 0xa0}, reason...), models.RevertError, "not enough"},
+		{"panic", append([]byte{0x4e, 0x48, 0x7b, 0x71}, panicCode...), models.RevertPanic, "arithmetic overflow or underflow"},
+		{"custom", append(custom.ID[:4:4], customArgs...), models.RevertCustom, ""},
+		{"unknown selector", []byte{0xde, 0xad, 0xbe, 0xef}, models.RevertUnknown, ""},
+		{"empty", nil, models.RevertUnknown, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revert := DecodeRevert(tt.data, nil, contractABI)
+			if revert.Kind != tt.kind || revert.Reason != tt.reason {
+				t.Errorf("revert = %+v, want %s %q", revert, tt.kind, tt.reason)
+			}
+		})
+	}
+
+	revert := DecodeRevert(append(custom.ID[:4:4], customArgs...), contractABI)
+	if revert.Name != "InsufficientBalance" || len(revert.Arguments) != 2 || revert.Arguments[1].Value != "2" {
+		t.Errorf("custom error = %+v", revert)
+	}
+}
+
+func mustType(name string) abi.Type {
+	typ, err := abi.NewType(name, "", nil)
+	if err != nil {
+		panic(err)
+	}
+	return typ
+}
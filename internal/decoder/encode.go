@@ -0,0 +1,224 @@
+package decoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// EncodeArguments ABI-encodes JSON values against args, accepting the forms
+// RenderValue produces: integers as JSON numbers or decimal or 0x-prefixed
+// hex strings, addresses and byte strings as hex strings, arrays as lists
+// and tuples as lists or as objects keyed by component name.
+func EncodeArguments(args abi.Arguments, values []json.RawMessage) ([]byte, error) {
+	if len(values) != len(args) {
+		return nil, fmt.Errorf("got %d arguments, want %d", len(values), len(args))
+	}
+
+	converted := make([]interface{}, len(args))
+	for i, arg := range args {
+		v, err := convertValue(arg.Type, values[i])
+		if err != nil {
+			return nil, fmt.Errorf("argument %d (%s): %w", i, arg.Name, err)
+		}
+		converted[i] = v.Interface()
+	}
+	return args.Pack(converted...)
+}
+
+// convertValue converts a JSON value to the Go type go-ethereum packs t
+// from.
+func convertValue(t abi.Type, raw json.RawMessage) (reflect.Value, error) {
+	v := reflect.New(t.GetType()).Elem()
+
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		n, err := jsonInteger(raw)
+		if err != nil {
+			return v, err
+		}
+		if err := checkIntRange(t, n); err != nil {
+			return v, err
+		}
+		switch v.Kind() {
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			v.SetInt(n.Int64())
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			v.SetUint(n.Uint64())
+		default:
+			v.Set(reflect.ValueOf(n))
+		}
+
+	case abi.BoolTy:
+		var b bool
+		if err := json.Unmarshal(raw, &b); err != nil {
+			return v, fmt.Errorf("%s is not a bool", raw)
+		}
+		v.SetBool(b)
+
+	case abi.StringTy:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return v, fmt.Errorf("%s is not a string", raw)
+		}
+		v.SetString(s)
+
+	case abi.AddressTy:
+		s, err := jsonString(raw)
+		if err != nil || !common.IsHexAddress(s) {
+			return v, fmt.Errorf("%s is not an address", raw)
+		}
+		v.Set(reflect.ValueOf(common.HexToAddress(s)))
+
+	case abi.BytesTy:
+		b, err := jsonBytes(raw)
+		if err != nil {
+			return v, err
+		}
+		v.SetBytes(b)
+
+	case abi.FixedBytesTy, abi.FunctionTy:
+		b, err := jsonBytes(raw)
+		if err != nil {
+			return v, err
+		}
+		if len(b) > v.Len() {
+			return v, fmt.Errorf("%s is longer than %d bytes", raw, v.Len())
+		}
+		// Shorter values are right-padded, as Solidity does for bytesN.
+		reflect.Copy(v, reflect.ValueOf(b))
+
+	case abi.SliceTy, abi.ArrayTy:
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return v, fmt.Errorf("%s is not a list", raw)
+		}
+		if t.T == abi.ArrayTy && len(items) != t.Size {
+			return v, fmt.Errorf("got %d items, want %d", len(items), t.Size)
+		}
+		if t.T == abi.SliceTy {
+			v.Set(reflect.MakeSlice(v.Type(), len(items), len(items)))
+		}
+		for i, item := range items {
+			elem, err := convertValue(*t.Elem, item)
+			if err != nil {
+				return v, fmt.Errorf("item %d: %w", i, err)
+			}
+			v.Index(i).Set(elem)
+		}
+
+	case abi.TupleTy:
+		fields, err := tupleFields(t, raw)
+		if err != nil {
+			return v, err
+		}
+		for i, elem := range t.TupleElems {
+			field, err := convertValue(*elem, fields[i])
+			if err != nil {
+				return v, fmt.Errorf("%s: %w", t.TupleRawNames[i], err)
+			}
+			v.Field(i).Set(field)
+		}
+
+	default:
+		return v, fmt.Errorf("unsupported type %s", t)
+	}
+
+	return v, nil
+}
+
+// tupleFields returns the component values of a tuple given as a list or as
+// an object keyed by component name.
+func tupleFields(t abi.Type, raw json.RawMessage) ([]json.RawMessage, error) {
+	var fields []json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err == nil {
+		if len(fields) != len(t.TupleElems) {
+			return nil, fmt.Errorf("got %d tuple components, want %d", len(fields), len(t.TupleElems))
+		}
+		return fields, nil
+	}
+
+	var named map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &named); err != nil {
+		return nil, fmt.Errorf("%s is not a tuple", raw)
+	}
+	fields = make([]json.RawMessage, len(t.TupleElems))
+	for i, name := range t.TupleRawNames {
+		value, ok := named[name]
+		if !ok {
+			return nil, fmt.Errorf("missing tuple component %q", name)
+		}
+		fields[i] = value
+	}
+	return fields, nil
+}
+
+// jsonInteger parses a JSON number or a string holding a decimal or
+// 0x-prefixed hex integer.
+func jsonInteger(raw json.RawMessage) (*big.Int, error) {
+	text := string(bytes.TrimSpace(raw))
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(raw, &text); err != nil {
+			return nil, err
+		}
+	}
+
+	n, ok := new(big.Int), false
+	negative := strings.HasPrefix(text, "-")
+	digits := strings.TrimPrefix(text, "-")
+	if len(digits) > 2 && (strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0X")) {
+		_, ok = n.SetString(digits[2:], 16)
+	} else {
+		_, ok = n.SetString(digits, 10)
+	}
+	if !ok {
+		return nil, fmt.Errorf("%s is not an integer", raw)
+	}
+	if negative {
+		n.Neg(n)
+	}
+	return n, nil
+}
+
+// checkIntRange reports whether n fits in the integer type t.
+func checkIntRange(t abi.Type, n *big.Int) error {
+	if t.T == abi.UintTy {
+		if n.Sign() < 0 || n.BitLen() > t.Size {
+			return fmt.Errorf("%s does not fit in uint%d", n, t.Size)
+		}
+		return nil
+	}
+
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(t.Size-1))
+	if n.Cmp(limit) >= 0 || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+		return fmt.Errorf("%s does not fit in int%d", n, t.Size)
+	}
+	return nil
+}
+
+func jsonString(raw json.RawMessage) (string, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return "", fmt.Errorf("%s is not a string", raw)
+	}
+	return s, nil
+}
+
+func jsonBytes(raw json.RawMessage) ([]byte, error) {
+	s, err := jsonString(raw)
+	if err != nil {
+		return nil, err
+	}
+	b, err := hexutil.Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not 0x-prefixed hex", raw)
+	}
+	return b, nil
+}
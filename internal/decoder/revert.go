@@ -0,0 +1,84 @@
+package decoder
+
+import (
+	"bytes"
+	"math/big"
+
+	"eth-explorer-api/internal/models"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+var (
+	errorSelector = []byte{0x08, 0xc3, 0x79, 0xa0} // Error(string)
+	panicSelector = []byte{0x4e, 0x48, 0x7b, 0x71} // Panic(uint256)
+)
+
+// panicReasons describes the Solidity panic codes.
+var panicReasons = map[uint64]string{
+	0x00: "generic compiler panic",
+	0x01: "assertion failed",
+	0x11: "arithmetic overflow or underflow",
+	0x12: "division or modulo by zero",
+	0x21: "invalid enum value",
+	0x22: "incorrectly encoded storage byte array",
+	0x31: "pop on empty array",
+	0x32: "array index out of bounds",
+	0x41: "out of memory",
+	0x51: "call to an uninitialized internal function",
+}
+
+// DecodeRevert decodes the data of a reverted call: an Error(string) reason,
+// a Panic(uint256) code, or a custom error declared in one of abis, which
+// may be nil.
+func DecodeRevert(data []byte, abis ...*abi.ABI) *models.Revert {
+	revert := &models.Revert{Kind: models.RevertUnknown, Data: hexutil.Encode(data)}
+	if len(data) < 4 {
+		return revert
+	}
+
+	switch {
+	case bytes.Equal(data[:4], errorSelector):
+		reason, err := abi.UnpackRevert(data)
+		if err != nil {
+			return revert
+		}
+		revert.Kind = models.RevertError
+		revert.Reason = reason
+		return revert
+
+	case bytes.Equal(data[:4], panicSelector) && len(data) == 36:
+		code := new(big.Int).SetBytes(data[4:])
+		revert.Kind = models.RevertPanic
+		revert.PanicCode = hexutil.EncodeBig(code)
+		revert.Reason = "unknown panic code"
+		if code.IsUint64() {
+			if reason, ok := panicReasons[code.Uint64()]; ok {
+				revert.Reason = reason
+			}
+		}
+		return revert
+	}
+
+	for _, contractABI := range abis {
+		if contractABI == nil {
+			continue
+		}
+		abiErr, err := contractABI.ErrorByID([4]byte(data[:4]))
+		if err != nil {
+			continue
+		}
+		args, err := DecodeArguments(abiErr.Inputs, data[4:])
+		if err != nil {
+			continue
+		}
+		revert.Kind = models.RevertCustom
+		revert.Name = abiErr.Name
+		revert.Signature = abiErr.Sig
+		revert.Arguments = args
+		return revert
+	}
+
+	return revert
+}
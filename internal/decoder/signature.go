@@ -0,0 +1,239 @@
+package decoder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// ParseSignature parses a human-readable signature such as
+// "transfer(address,uint256)" or
+// "Transfer(address indexed from, address indexed to, uint256 value)" into
+// its name and arguments. Tuples are written in parentheses, e.g.
+// "aggregate3((address,bool,bytes)[])". Unnamed arguments and tuple
+// components are named argN.
+func ParseSignature(sig string) (string, abi.Arguments, error) {
+	sig = strings.TrimSpace(sig)
+	open := strings.IndexByte(sig, '(')
+	if open <= 0 || !strings.HasSuffix(sig, ")") {
+		return "", nil, fmt.Errorf("invalid signature %q", sig)
+	}
+
+	name := strings.TrimSpace(sig[:open])
+	args, err := parseArguments(sig[open+1 : len(sig)-1])
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid signature %q: %w", sig, err)
+	}
+
+	return name, args, nil
+}
+
+// functionModifiers are the keywords allowed between a function's
+// parameters and its return values.
+var functionModifiers = map[string]bool{
+	"external":   true,
+	"public":     true,
+	"view":       true,
+	"pure":       true,
+	"payable":    true,
+	"nonpayable": true,
+}
+
+// ParseFunction parses a human-readable function signature with optional
+// return values, in Solidity form, e.g.
+// "function balanceOf(address owner) external view returns (uint256)", or
+// in the short form "balanceOf(address)(uint256)".
+func ParseFunction(sig string) (*abi.Method, error) {
+	sig = strings.TrimSpace(sig)
+	rest := strings.TrimSpace(strings.TrimPrefix(sig, "function "))
+	open := strings.IndexByte(rest, '(')
+	if open <= 0 {
+		return nil, fmt.Errorf("invalid signature %q", sig)
+	}
+	end, err := matchingParen(rest[open:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature %q: %w", sig, err)
+	}
+	name, inputs, err := ParseSignature(rest[:open+end+1])
+	if err != nil {
+		return nil, err
+	}
+	rest = strings.TrimSpace(rest[open+end+1:])
+
+	mutability := ""
+	for rest != "" && !strings.HasPrefix(rest, "(") {
+		word, after, _ := strings.Cut(rest, " ")
+		if strings.HasPrefix(word, "returns") {
+			rest = strings.TrimSpace(strings.TrimPrefix(rest, "returns"))
+			if !strings.HasPrefix(rest, "(") {
+				return nil, fmt.Errorf("invalid signature %q: returns must be followed by a parameter list", sig)
+			}
+			break
+		}
+		if !functionModifiers[word] {
+			return nil, fmt.Errorf("invalid signature %q: unexpected %q", sig, word)
+		}
+		if word != "external" && word != "public" {
+			mutability = word
+		}
+		rest = strings.TrimSpace(after)
+	}
+
+	var outputs abi.Arguments
+	if rest != "" {
+		if !strings.HasSuffix(rest, ")") {
+			return nil, fmt.Errorf("invalid signature %q", sig)
+		}
+		if outputs, err = parseArguments(rest[1 : len(rest)-1]); err != nil {
+			return nil, fmt.Errorf("invalid signature %q: %w", sig, err)
+		}
+	}
+
+	method := abi.NewMethod(name, name, abi.Function, mutability, mutability == "view" || mutability == "pure", mutability == "payable", inputs, outputs)
+	return &method, nil
+}
+
+// parseArguments parses a comma-separated parameter list into arguments.
+func parseArguments(list string) (abi.Arguments, error) {
+	params, err := parseParams(list)
+	if err != nil {
+		return nil, err
+	}
+
+	args := make(abi.Arguments, len(params))
+	for i, p := range params {
+		typ, err := abi.NewType(p.Type, "", p.Components)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = abi.Argument{Name: p.Name, Type: typ, Indexed: p.Indexed}
+	}
+	return args, nil
+}
+
+// parseParams parses a comma-separated parameter list.
+func parseParams(list string) ([]abi.ArgumentMarshaling, error) {
+	parts, err := splitTopLevel(list)
+	if err != nil {
+		return nil, err
+	}
+
+	params := make([]abi.ArgumentMarshaling, 0, len(parts))
+	for i, part := range parts {
+		p, err := parseParam(part)
+		if err != nil {
+			return nil, err
+		}
+		if p.Name == "" {
+			p.Name = "arg" + strconv.Itoa(i)
+		}
+		params = append(params, p)
+	}
+	return params, nil
+}
+
+// dataLocations are the Solidity data locations that may follow a
+// parameter type. They do not affect the ABI.
+var dataLocations = map[string]bool{"memory": true, "calldata": true, "storage": true}
+
+// parseParam parses "type [indexed] [location] [name]", where type may be a
+// parenthesized tuple with array suffixes.
+func parseParam(param string) (abi.ArgumentMarshaling, error) {
+	param = strings.TrimSpace(param)
+	if param == "" {
+		return abi.ArgumentMarshaling{}, fmt.Errorf("empty parameter")
+	}
+
+	var p abi.ArgumentMarshaling
+	var rest string
+	if strings.HasPrefix(param, "(") {
+		end, err := matchingParen(param)
+		if err != nil {
+			return p, err
+		}
+		components, err := parseParams(param[1:end])
+		if err != nil {
+			return p, err
+		}
+
+		suffixEnd := end + 1
+		for suffixEnd < len(param) && param[suffixEnd] != ' ' {
+			suffixEnd++
+		}
+		p.Type = "tuple" + param[end+1:suffixEnd]
+		p.Components = components
+		rest = param[suffixEnd:]
+	} else {
+		typ, after, _ := strings.Cut(param, " ")
+		p.Type = typ
+		rest = after
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) > 0 && fields[0] == "indexed" {
+		p.Indexed = true
+		fields = fields[1:]
+	}
+	if len(fields) > 0 && dataLocations[fields[0]] {
+		fields = fields[1:]
+	}
+	switch len(fields) {
+	case 0:
+	case 1:
+		p.Name = fields[0]
+	default:
+		return p, fmt.Errorf("unexpected %q in parameter %q", strings.Join(fields[1:], " "), param)
+	}
+
+	return p, nil
+}
+
+// splitTopLevel splits list on commas that are not nested in parentheses.
+func splitTopLevel(list string) ([]string, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range list {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses")
+			}
+		case ',':
+			if depth == 0 {
+				parts = append(parts, list[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses")
+	}
+	return append(parts, list[start:]), nil
+}
+
+// matchingParen returns the index of the parenthesis closing the one that
+// opens s.
+func matchingParen(s string) (int, error) {
+	depth := 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unbalanced parentheses")
+}
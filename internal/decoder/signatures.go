@@ -0,0 +1,80 @@
+package decoder
+
+import (
+	_ "embed"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+//go:embed functions.txt
+var functionsFile string
+
+//go:embed events.txt
+var eventsFile string
+
+var (
+	functionsOnce       sync.Once
+	functionsBySelector map[[4]byte][]abi.Method
+
+	eventsOnce    sync.Once
+	eventsByTopic map[common.Hash][]abi.Event
+)
+
+// LookupFunctions returns the well-known functions whose selector matches
+// the first four bytes of calldata. Selectors can collide, so more than one
+// method may be returned.
+func LookupFunctions(selector []byte) []abi.Method {
+	if len(selector) < 4 {
+		return nil
+	}
+
+	functionsOnce.Do(func() {
+		functionsBySelector = make(map[[4]byte][]abi.Method)
+		for _, sig := range signatureLines(functionsFile) {
+			name, args, err := ParseSignature(sig)
+			if err != nil {
+				panic("decoder: bad embedded function signature: " + err.Error())
+			}
+			method := abi.NewMethod(name, name, abi.Function, "", false, false, args, nil)
+			functionsBySelector[[4]byte(method.ID)] = append(functionsBySelector[[4]byte(method.ID)], method)
+		}
+	})
+
+	return functionsBySelector[[4]byte(selector[:4])]
+}
+
+// LookupEvents returns the well-known events whose topic0 is topic. Events
+// such as the ERC-20 and ERC-721 Transfer share a topic, so more than one
+// event may be returned.
+func LookupEvents(topic common.Hash) []abi.Event {
+	eventsOnce.Do(func() {
+		eventsByTopic = make(map[common.Hash][]abi.Event)
+		for _, sig := range signatureLines(eventsFile) {
+			name, args, err := ParseSignature(sig)
+			if err != nil {
+				panic("decoder: bad embedded event signature: " + err.Error())
+			}
+			event := abi.NewEvent(name, name, false, args)
+			eventsByTopic[event.ID] = append(eventsByTopic[event.ID], event)
+		}
+	})
+
+	return eventsByTopic[topic]
+}
+
+// signatureLines returns the non-empty, non-comment lines of an embedded
+// signature table.
+func signatureLines(file string) []string {
+	var lines []string
+	for _, line := range strings.Split(file, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,175 @@
+package explorer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"eth-explorer-api/internal/models"
+)
+
+// DefaultBaseURL is the Etherscan mainnet API endpoint.
+const DefaultBaseURL = "https://api.etherscan.io/api"
+
+const defaultTimeout = 15 * time.Second
+
+// noTransactionsMessage is what Etherscan reports instead of an empty list.
+const noTransactionsMessage = "No transactions found"
+
+// Client talks to an Etherscan-compatible block explorer API such as
+// Etherscan, its testnet and L2 deployments, or Blockscout.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewClient creates a Client for the API at baseURL. An empty baseURL
+// selects DefaultBaseURL.
+func NewClient(baseURL, apiKey string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		httpClient: &http.Client{
+			Timeout: defaultTimeout,
+		},
+	}
+}
+
+// APIError is returned when the explorer answers with a non-"1" status.
+type APIError struct {
+	Message string
+	Result  string
+}
+
+func (e *APIError) Error() string {
+	if e.Result != "" && e.Result != e.Message {
+		return fmt.Sprintf("etherscan API error: %s: %s", e.Message, e.Result)
+	}
+	return fmt.Sprintf("etherscan API error: %s", e.Message)
+}
+
+// HTTPError is returned when the explorer answers with a status other than
+// 200 OK.
+type HTTPError struct {
+	StatusCode int
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("explorer API returned HTTP %d", e.StatusCode)
+}
+
+type envelope struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Result  json.RawMessage `json:"result"`
+}
+
+// Call performs a module/action request and decodes the envelope's result
+// field into result.
+func (c *Client) Call(ctx context.Context, module, action string, params url.Values, result interface{}) error {
+	query := url.Values{}
+	for key, values := range params {
+		query[key] = values
+	}
+	query.Set("module", module)
+	query.Set("action", action)
+	if c.apiKey != "" {
+		query.Set("apikey", c.apiKey)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to build explorer request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach explorer API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return &HTTPError{StatusCode: resp.StatusCode}
+	}
+
+	var env envelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if env.Status != "1" {
+		apiErr := &APIError{Message: env.Message}
+		// Error details are usually a string, but some errors carry an empty list.
+		_ = json.Unmarshal(env.Result, &apiErr.Result)
+		return apiErr
+	}
+
+	if err := json.Unmarshal(env.Result, result); err != nil {
+		return fmt.Errorf("failed to unmarshal result: %w", err)
+	}
+
+	return nil
+}
+
+// TransactionList returns the normal transactions sent from or to address.
+func (c *Client) TransactionList(ctx context.Context, address string) ([]models.Transaction, error) {
+	params := url.Values{
+		"address":    {address},
+		"startblock": {"0"},
+		"endblock":   {"99999999"},
+		"sort":       {"asc"},
+	}
+
+	var transactions []models.Transaction
+	if err := c.Call(ctx, "account", "txlist", params, &transactions); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.Message == noTransactionsMessage {
+			return []models.Transaction{}, nil
+		}
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// ContractABI returns the JSON ABI of a verified contract.
+func (c *Client) ContractABI(ctx context.Context, address string) (string, error) {
+	var abi string
+	if err := c.Call(ctx, "contract", "getabi", url.Values{"address": {address}}, &abi); err != nil {
+		return "", err
+	}
+
+	return abi, nil
+}
+
+// SourceCode is a single entry of a getsourcecode response.
+type SourceCode struct {
+	SourceCode   string `json:"SourceCode"`
+	ContractName string `json:"ContractName"`
+}
+
+// ContractSource returns the verified source code of a contract.
+func (c *Client) ContractSource(ctx context.Context, address string) ([]SourceCode, error) {
+	var sources []SourceCode
+	if err := c.Call(ctx, "contract", "getsourcecode", url.Values{"address": {address}}, &sources); err != nil {
+		return nil, err
+	}
+
+	return sources, nil
+}
@@ -0,0 +1,123 @@
+package explorer_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"eth-explorer-api/internal/explorer"
+	"eth-explorer-api/internal/explorer/explorertest"
+	"eth-explorer-api/internal/models"
+)
+
+const contractAddress = "0x00000000000000000000000000000000000070c0"
+
+func TestClient(t *testing.T) {
+	server := explorertest.NewServer()
+	defer server.Close()
+	server.APIKey = "test-key"
+	server.AddTransactions(contractAddress, models.Transaction{Hash: "0x01"}, models.Transaction{Hash: "0x02"})
+	server.SetABI(contractAddress, `[{"type":"fallback"}]`)
+	server.SetSource(contractAddress, "contract Token {}")
+
+	client := explorer.NewClient(server.URL, "test-key")
+	ctx := context.Background()
+
+	t.Run("TransactionList", func(t *testing.T) {
+		txs, err := client.TransactionList(ctx, contractAddress)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(txs) != 2 || txs[0].Hash != "0x01" || txs[1].Hash != "0x02" {
+			t.Errorf("transactions = %+v", txs)
+		}
+	})
+
+	t.Run("TransactionListEmpty", func(t *testing.T) {
+		txs, err := client.TransactionList(ctx, "0x00000000000000000000000000000000000000b0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if txs == nil || len(txs) != 0 {
+			t.Errorf("transactions = %+v, want empty list", txs)
+		}
+	})
+
+	t.Run("ContractABI", func(t *testing.T) {
+		abi, err := client.ContractABI(ctx, contractAddress)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if abi != `[{"type":"fallback"}]` {
+			t.Errorf("abi = %s", abi)
+		}
+	})
+
+	t.Run("ContractSource", func(t *testing.T) {
+		sources, err := client.ContractSource(ctx, contractAddress)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sources) != 1 || sources[0].SourceCode != "contract Token {}" {
+			t.Errorf("sources = %+v", sources)
+		}
+	})
+
+	t.Run("APIError", func(t *testing.T) {
+		_, err := client.ContractABI(ctx, "0x00000000000000000000000000000000000000b0")
+
+		var apiErr *explorer.APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("err = %v, want *APIError", err)
+		}
+		if apiErr.Message != "NOTOK" || apiErr.Result != "Contract source code not verified" {
+			t.Errorf("apiErr = %+v", apiErr)
+		}
+	})
+
+	t.Run("InvalidAPIKey", func(t *testing.T) {
+		_, err := explorer.NewClient(server.URL, "wrong").ContractABI(ctx, contractAddress)
+
+		var apiErr *explorer.APIError
+		if !errors.As(err, &apiErr) || apiErr.Result != "Invalid API Key" {
+			t.Fatalf("err = %v, want invalid API key error", err)
+		}
+	})
+}
+
+func TestClientHTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	_, err := explorer.NewClient(server.URL, "").ContractABI(context.Background(), contractAddress)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	var httpErr *explorer.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusBadGateway {
+		t.Errorf("err = %v, want HTTP 502 error", err)
+	}
+}
+
+func TestClientContextDeadline(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := explorer.NewClient(server.URL, "").ContractABI(ctx, contractAddress)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,109 @@
+// Package explorertest provides an in-process stand-in for an
+// Etherscan-compatible API, for use in tests and offline development.
+package explorertest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"eth-explorer-api/internal/models"
+)
+
+// Server serves the txlist, getabi and getsourcecode actions from
+// in-memory fixtures.
+type Server struct {
+	*httptest.Server
+
+	// APIKey, when set, must be supplied by clients as the apikey parameter.
+	APIKey string
+
+	mu           sync.Mutex
+	transactions map[string][]models.Transaction
+	abis         map[string]string
+	sources      map[string]string
+}
+
+// NewServer starts a Server. Callers should Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		transactions: make(map[string][]models.Transaction),
+		abis:         make(map[string]string),
+		sources:      make(map[string]string),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// AddTransactions appends transactions to the history of address.
+func (s *Server) AddTransactions(address string, txs ...models.Transaction) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := strings.ToLower(address)
+	s.transactions[key] = append(s.transactions[key], txs...)
+}
+
+// SetABI registers the verified ABI of a contract.
+func (s *Server) SetABI(address, abi string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.abis[strings.ToLower(address)] = abi
+}
+
+// SetSource registers the verified source code of a contract.
+func (s *Server) SetSource(address, source string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sources[strings.ToLower(address)] = source
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	if s.APIKey != "" && query.Get("apikey") != s.APIKey {
+		writeEnvelope(w, "0", "NOTOK", "Invalid API Key")
+		return
+	}
+
+	address := strings.ToLower(query.Get("address"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch query.Get("module") + "." + query.Get("action") {
+	case "account.txlist":
+		txs := s.transactions[address]
+		if len(txs) == 0 {
+			writeEnvelope(w, "0", "No transactions found", []models.Transaction{})
+			return
+		}
+		writeEnvelope(w, "1", "OK", txs)
+	case "contract.getabi":
+		abi, ok := s.abis[address]
+		if !ok {
+			writeEnvelope(w, "0", "NOTOK", "Contract source code not verified")
+			return
+		}
+		writeEnvelope(w, "1", "OK", abi)
+	case "contract.getsourcecode":
+		source, ok := s.sources[address]
+		if !ok {
+			writeEnvelope(w, "0", "NOTOK", "Contract source code not verified")
+			return
+		}
+		writeEnvelope(w, "1", "OK", []map[string]string{{"SourceCode": source}})
+	default:
+		writeEnvelope(w, "0", "NOTOK", "Error! Missing Or invalid Module name")
+	}
+}
+
+func writeEnvelope(w http.ResponseWriter, status, message string, result interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  status,
+		"message": message,
+		"result":  result,
+	})
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"eth-explorer-api/internal/models"
+	"eth-explorer-api/internal/services"
+	"eth-explorer-api/internal/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Machine-readable error codes reported in models.ErrorResponse.Code.
+const (
+	CodeInvalidInput        = "invalid_input"
+	CodeNotFound            = "not_found"
+	CodeUpstreamUnavailable = "upstream_unavailable"
+	CodeRateLimited         = "rate_limited"
+	CodeTimeout             = "timeout"
+	CodeStateUnavailable    = "state_unavailable"
+	CodeUnsupported         = "unsupported"
+	CodeInternal            = "internal_error"
+)
+
+var errorKinds = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{services.ErrInvalidInput, http.StatusBadRequest, CodeInvalidInput},
+	{services.ErrNotFound, http.StatusNotFound, CodeNotFound},
+	{services.ErrUpstreamUnavailable, http.StatusBadGateway, CodeUpstreamUnavailable},
+	{services.ErrRateLimited, http.StatusTooManyRequests, CodeRateLimited},
+	{services.ErrTimeout, http.StatusGatewayTimeout, CodeTimeout},
+	{services.ErrStateUnavailable, http.StatusNotImplemented, CodeStateUnavailable},
+	{services.ErrUnsupported, http.StatusNotImplemented, CodeUnsupported},
+}
+
+// renderError writes the error response for a failed service call. The
+// status and code are derived from the service error kind; errors without a
+// kind are reported as internal errors, and validation failures name the
+// offending field.
+func renderError(c *gin.Context, message string, err error) {
+	status, code := http.StatusInternalServerError, CodeInternal
+	for _, k := range errorKinds {
+		if errors.Is(err, k.kind) {
+			status, code = k.status, k.code
+			break
+		}
+	}
+
+	resp := models.ErrorResponse{
+		Error:   message,
+		Code:    code,
+		Message: err.Error(),
+	}
+
+	var fieldErr *validation.FieldError
+	if errors.As(err, &fieldErr) {
+		resp.Field = fieldErr.Field
+	}
+
+	c.JSON(status, resp)
+}
+
+// bindJSON decodes the request body into req, writing an invalid input
+// response and returning false if it is not valid JSON for req.
+func bindJSON(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Code:    CodeInvalidInput,
+			Message: err.Error(),
+		})
+		return false
+	}
+	return true
+}
@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 
 	"eth-explorer-api/internal/models"
 	"eth-explorer-api/internal/services"
@@ -19,45 +21,95 @@ func NewEthHandler(ethService *services.EthService) *EthHandler {
 	}
 }
 
+// GetBlock handles GET /api/v1/eth/block/:number. With ?full=true the
+// block's transactions are returned as full objects instead of hashes.
 func (h *EthHandler) GetBlock(c *gin.Context) {
 	blockNumber := c.Param("number")
 
-	block, err := h.ethService.GetBlock(blockNumber)
+	if c.Query("full") == "true" {
+		block, err := h.ethService.GetBlockWithTransactions(c.Request.Context(), blockNumber)
+		if err != nil {
+			renderError(c, "Failed to fetch block", err)
+			return
+		}
+
+		c.JSON(http.StatusOK, block)
+		return
+	}
+
+	block, err := h.ethService.GetBlock(c.Request.Context(), blockNumber)
+	if err != nil {
+		renderError(c, "Failed to fetch block", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, block)
+}
+
+// GetBlockWithdrawals handles GET /api/v1/eth/block/:number/withdrawals
+func (h *EthHandler) GetBlockWithdrawals(c *gin.Context) {
+	blockNumber := c.Param("number")
+
+	withdrawals, err := h.ethService.GetBlockWithdrawals(c.Request.Context(), blockNumber)
+	if err != nil {
+		renderError(c, "Failed to fetch block withdrawals", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, withdrawals)
+}
+
+// GetBlockByHash handles GET /api/v1/eth/block/hash/:hash
+func (h *EthHandler) GetBlockByHash(c *gin.Context) {
+	blockHash := c.Param("hash")
+
+	block, err := h.ethService.GetBlockByHash(c.Request.Context(), blockHash)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Failed to fetch block",
-			Message: err.Error(),
-		})
+		renderError(c, "Failed to fetch block", err)
 		return
 	}
 
 	c.JSON(http.StatusOK, block)
 }
 
+// GetTransaction handles GET /api/v1/eth/transaction/:hash. With
+// ?decode=true the input data is decoded against the target contract's ABI.
 func (h *EthHandler) GetTransaction(c *gin.Context) {
 	txHash := c.Param("hash")
 
-	transaction, err := h.ethService.GetTransaction(txHash)
+	getTransaction := h.ethService.GetTransaction
+	if c.Query("decode") == "true" {
+		getTransaction = h.ethService.GetDecodedTransaction
+	}
+
+	transaction, err := getTransaction(c.Request.Context(), txHash)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Failed to fetch transaction",
-			Message: err.Error(),
-		})
+		renderError(c, "Failed to fetch transaction", err)
 		return
 	}
 
 	c.JSON(http.StatusOK, transaction)
 }
 
+// GetTransactionReceipt handles GET /api/v1/eth/transaction/:hash/receipt
+func (h *EthHandler) GetTransactionReceipt(c *gin.Context) {
+	txHash := c.Param("hash")
+
+	receipt, err := h.ethService.GetTransactionReceipt(c.Request.Context(), txHash)
+	if err != nil {
+		renderError(c, "Failed to fetch transaction receipt", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, receipt)
+}
+
 func (h *EthHandler) GetBalance(c *gin.Context) {
 	address := c.Param("address")
 
-	balance, err := h.ethService.GetBalance(address)
+	balance, err := h.ethService.GetBalance(c.Request.Context(), address, c.Query("block"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Failed to fetch balance",
-			Message: err.Error(),
-		})
+		renderError(c, "Failed to fetch balance", err)
 		return
 	}
 
@@ -65,12 +117,9 @@ func (h *EthHandler) GetBalance(c *gin.Context) {
 }
 
 func (h *EthHandler) GetLatestBlock(c *gin.Context) {
-	block, err := h.ethService.GetLatestBlock()
+	block, err := h.ethService.GetLatestBlock(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Failed to fetch latest block",
-			Message: err.Error(),
-		})
+		renderError(c, "Failed to fetch latest block", err)
 		return
 	}
 
@@ -79,27 +128,101 @@ func (h *EthHandler) GetLatestBlock(c *gin.Context) {
 
 // GetGasPrice handles GET /api/v1/eth/gas-price
 func (h *EthHandler) GetGasPrice(c *gin.Context) {
-	gasPrice, err := h.ethService.GetGasPrice()
+	gasPrice, err := h.ethService.GetGasPrice(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Failed to fetch gas price",
-			Message: err.Error(),
-		})
+		renderError(c, "Failed to fetch gas price", err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gasPrice)
 }
 
+// TraceTransaction handles GET /api/v1/eth/transaction/:hash/trace
+func (h *EthHandler) TraceTransaction(c *gin.Context) {
+	trace, err := h.ethService.TraceTransaction(c.Request.Context(), c.Param("hash"))
+	if err != nil {
+		renderError(c, "Failed to trace transaction", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, trace)
+}
+
+// SendTransaction handles POST /api/v1/eth/transaction/send. The
+// transaction is accepted once the node has it in its mempool.
+func (h *EthHandler) SendTransaction(c *gin.Context) {
+	var req models.SendTransactionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	status, err := h.ethService.SendRawTransaction(c.Request.Context(), req.RawTransaction)
+	if err != nil {
+		renderError(c, "Failed to send transaction", err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, status)
+}
+
+// GetTransactionStatus handles GET /api/v1/eth/transaction/:hash/status.
+func (h *EthHandler) GetTransactionStatus(c *gin.Context) {
+	status, err := h.ethService.GetTransactionStatus(c.Request.Context(), c.Param("hash"))
+	if err != nil {
+		renderError(c, "Failed to fetch transaction status", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// Simulate handles POST /api/v1/eth/simulate.
+func (h *EthHandler) Simulate(c *gin.Context) {
+	var req models.SimulateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	sim, err := h.ethService.Simulate(c.Request.Context(), &req)
+	if err != nil {
+		renderError(c, "Failed to simulate transaction", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sim)
+}
+
+// GetGasFees handles GET /api/v1/eth/gas/fees. ?blocks=N overrides the
+// number of recent blocks the estimate is based on.
+func (h *EthHandler) GetGasFees(c *gin.Context) {
+	fees, err := h.ethService.GetGasFees(c.Request.Context(), c.Query("blocks"))
+	if err != nil {
+		renderError(c, "Failed to estimate gas fees", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, fees)
+}
+
+// GetGasHistory handles GET /api/v1/eth/gas/history. ?blocks=N sets the
+// number of recent blocks covered and ?bucket=minute or hour aggregates
+// them by period.
+func (h *EthHandler) GetGasHistory(c *gin.Context) {
+	history, err := h.ethService.GetGasHistory(c.Request.Context(), c.Query("blocks"), c.Query("bucket"))
+	if err != nil {
+		renderError(c, "Failed to fetch gas history", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
 func (h *EthHandler) GetTransactionHistory(c *gin.Context) {
 	address := c.Param("address")
 
-	history, err := h.ethService.GetTransactionHistory(address)
+	history, err := h.ethService.GetTransactionHistory(c.Request.Context(), address)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Failed to fetch transaction history",
-			Message: err.Error(),
-		})
+		renderError(c, "Failed to fetch transaction history", err)
 		return
 	}
 
@@ -110,42 +233,113 @@ func (h *EthHandler) GetTokenBalance(c *gin.Context) {
 	userAddress := c.Param("address")
 	tokenAddress := c.Param("tokenAddress")
 
-	balance, err := h.ethService.GetTokenBalance(userAddress, tokenAddress)
+	balance, err := h.ethService.GetTokenBalance(c.Request.Context(), userAddress, tokenAddress, c.Query("block"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Failed to fetch token balance",
-			Message: err.Error(),
-		})
+		renderError(c, "Failed to fetch token balance", err)
 		return
 	}
 
 	c.JSON(http.StatusOK, balance)
 }
 
-func (h *EthHandler) GetTokenTransfers(c *gin.Context) {
+// GetPortfolio handles GET /api/v1/eth/portfolio/:address
+func (h *EthHandler) GetPortfolio(c *gin.Context) {
 	address := c.Param("address")
+	tokens := strings.Join(c.QueryArray("tokens"), ",")
+
+	portfolio, err := h.ethService.GetPortfolio(c.Request.Context(), address, tokens, c.Query("block"))
+	if err != nil {
+		renderError(c, "Failed to fetch portfolio", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, portfolio)
+}
+
+// GetNFT handles GET /api/v1/eth/nft/:contract/:tokenId
+func (h *EthHandler) GetNFT(c *gin.Context) {
+	contract := c.Param("contract")
+	tokenID := c.Param("tokenId")
 
-	transfers, err := h.ethService.GetTokenTransfers(address)
+	nft, err := h.ethService.GetNFT(c.Request.Context(), contract, tokenID, c.Query("block"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Failed to fetch token transfers",
-			Message: err.Error(),
-		})
+		renderError(c, "Failed to fetch NFT", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, nft)
+}
+
+// GetNFTTransfers handles GET /api/v1/eth/nft-transfers/:address
+func (h *EthHandler) GetNFTTransfers(c *gin.Context) {
+	transfers, err := h.ethService.GetNFTTransfers(c.Request.Context(), transferQuery(c))
+	if err != nil {
+		renderError(c, "Failed to fetch NFT transfers", err)
 		return
 	}
 
 	c.JSON(http.StatusOK, transfers)
 }
 
+// GetTokenMetadata handles GET /api/v1/eth/token/:address
+func (h *EthHandler) GetTokenMetadata(c *gin.Context) {
+	tokenAddress := c.Param("address")
+
+	metadata, err := h.ethService.GetTokenMetadata(c.Request.Context(), tokenAddress, c.Query("block"))
+	if err != nil {
+		renderError(c, "Failed to fetch token metadata", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, metadata)
+}
+
+func (h *EthHandler) GetTokenTransfers(c *gin.Context) {
+	transfers, err := h.ethService.GetTokenTransfers(c.Request.Context(), transferQuery(c))
+	if err != nil {
+		renderError(c, "Failed to fetch token transfers", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, transfers)
+}
+
+// transferQuery reads the parameters shared by the token and NFT transfer
+// endpoints.
+func transferQuery(c *gin.Context) services.TokenTransferQuery {
+	return services.TokenTransferQuery{
+		Address:   c.Param("address"),
+		Token:     c.Query("token"),
+		Direction: c.Query("direction"),
+		FromBlock: c.Query("fromBlock"),
+		ToBlock:   c.Query("toBlock"),
+		Limit:     c.Query("limit"),
+		Cursor:    c.Query("cursor"),
+	}
+}
+
+// Call handles POST /api/v1/eth/call
+func (h *EthHandler) Call(c *gin.Context) {
+	var req models.CallRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	result, err := h.ethService.Call(c.Request.Context(), &req)
+	if err != nil {
+		renderError(c, "Failed to call contract", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 func (h *EthHandler) GetContractABI(c *gin.Context) {
 	address := c.Param("address")
 
-	abi, err := h.ethService.GetContractABI(address)
+	abi, err := h.ethService.GetContractABI(c.Request.Context(), address)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Failed to fetch contract ABI",
-			Message: err.Error(),
-		})
+		renderError(c, "Failed to fetch contract ABI", err)
 		return
 	}
 
@@ -155,12 +349,9 @@ func (h *EthHandler) GetContractABI(c *gin.Context) {
 func (h *EthHandler) GetContractSource(c *gin.Context) {
 	address := c.Param("address")
 
-	source, err := h.ethService.GetContractSource(address)
+	source, err := h.ethService.GetContractSource(c.Request.Context(), address)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Failed to fetch contract source",
-			Message: err.Error(),
-		})
+		renderError(c, "Failed to fetch contract source", err)
 		return
 	}
 
@@ -168,15 +359,27 @@ func (h *EthHandler) GetContractSource(c *gin.Context) {
 }
 
 func (h *EthHandler) GetEventLogs(c *gin.Context) {
-	address := c.Param("address")
-	topics := c.QueryArray("topics")
+	query := services.EventLogQuery{
+		Address:   c.Param("address"),
+		Topics:    c.QueryArray("topics"),
+		FromBlock: c.Query("fromBlock"),
+		ToBlock:   c.Query("toBlock"),
+		BlockHash: c.Query("blockHash"),
+		Limit:     c.Query("limit"),
+		Cursor:    c.Query("cursor"),
+	}
+	for i := range query.TopicFilters {
+		query.TopicFilters[i] = strings.Join(c.QueryArray("topic"+strconv.Itoa(i)), ",")
+	}
+
+	getEventLogs := h.ethService.GetEventLogs
+	if c.Query("decode") == "true" {
+		getEventLogs = h.ethService.GetDecodedEventLogs
+	}
 
-	logs, err := h.ethService.GetEventLogs(address, topics)
+	logs, err := getEventLogs(c.Request.Context(), query)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Failed to fetch event logs",
-			Message: err.Error(),
-		})
+		renderError(c, "Failed to fetch event logs", err)
 		return
 	}
 
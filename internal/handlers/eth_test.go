@@ -0,0 +1,1285 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"eth-explorer-api/internal/explorer"
+	"eth-explorer-api/internal/explorer/explorertest"
+	"eth-explorer-api/internal/handlers"
+	"eth-explorer-api/internal/models"
+	"eth-explorer-api/internal/services"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	senderKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	senderAddr    = crypto.PubkeyToAddress(senderKey.PublicKey)
+	recipientAddr = common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	tokenAddr     = common.HexToAddress("0x00000000000000000000000000000000000070c0")
+
+	senderFunds   = new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))
+	transferValue = big.NewInt(params.Ether)
+)
+
+// tokenRuntimeCode is a minimal token stand-in. decimals() returns 2 and
+// symbol() returns "TKN" as bytes32, like pre-standard tokens such as MKR.
+// Every other call emits Transfer(msg.sender, address(calldata[4:36]), 1000)
+// and returns 1000, so it answers balanceOf(address) and totalSupply() and
+// produces transfer logs for the same fixture. It has no name().
+func tokenRuntimeCode() []byte {
+	transferTopic := crypto.Keccak256([]byte("Transfer(address,address,uint256)"))
+
+	decimals := []byte{
+		0x5b,       // JUMPDEST
+		0x60, 0x02, // PUSH1 2
+		0x60, 0x00, // PUSH1 0
+		0x52,       // MSTORE
+		0x60, 0x20, // PUSH1 32
+		0x60, 0x00, // PUSH1 0
+		0xf3, // RETURN
+	}
+	symbol := append([]byte{0x5b, 0x7f}, common.RightPadBytes([]byte("TKN"), 32)...) // JUMPDEST, PUSH32 "TKN"
+	symbol = append(symbol,
+		0x60, 0x00, // PUSH1 0
+		0x52,       // MSTORE
+		0x60, 0x20, // PUSH1 32
+		0x60, 0x00, // PUSH1 0
+		0xf3, // RETURN
+	)
+
+	transfer := []byte{
+		0x50,             // POP
+		0x61, 0x03, 0xe8, // PUSH2 1000
+		0x60, 0x00, // PUSH1 0
+		0x52,       // MSTORE
+		0x60, 0x04, // PUSH1 4
+		0x35, // CALLDATALOAD
+		0x33, // CALLER
+		0x7f, // PUSH32 topic0
+	}
+	transfer = append(transfer, transferTopic...)
+	transfer = append(transfer,
+		0x60, 0x20, // PUSH1 32
+		0x60, 0x00, // PUSH1 0
+		0xa3,       // LOG3
+		0x60, 0x20, // PUSH1 32
+		0x60, 0x00, // PUSH1 0
+		0xf3, // RETURN
+	)
+
+	// The dispatcher jumps past the transfer code to the decimals and
+	// symbol handlers.
+	const dispatchLen = 26
+	decimalsDest := byte(dispatchLen + len(transfer))
+	symbolDest := decimalsDest + byte(len(decimals))
+	code := []byte{
+		0x60, 0x00, // PUSH1 0
+		0x35,       // CALLDATALOAD
+		0x60, 0xe0, // PUSH1 224
+		0x1c,                         // SHR
+		0x80,                         // DUP1
+		0x63, 0x31, 0x3c, 0xe5, 0x67, // PUSH4 decimals()
+		0x14,               // EQ
+		0x60, decimalsDest, // PUSH1 decimals handler
+		0x57,                         // JUMPI
+		0x80,                         // DUP1
+		0x63, 0x95, 0xd8, 0x9b, 0x41, // PUSH4 symbol()
+		0x14,             // EQ
+		0x60, symbolDest, // PUSH1 symbol handler
+		0x57, // JUMPI
+	}
+	code = append(code, transfer...)
+	code = append(code, decimals...)
+	return append(code, symbol...)
+}
+
+// revertCode returns runtime code that reverts with data.
+func revertCode(data []byte) []byte {
+	var code []byte
+	for offset := 0; offset < len(data); offset += 32 {
+		code = append(code, 0x7f) // PUSH32 word
+		code = append(code, common.RightPadBytes(data[offset:min(offset+32, len(data))], 32)...)
+		code = append(code, 0x60, byte(offset), 0x52) // PUSH1 offset, MSTORE
+	}
+	return append(code,
+		0x60, byte(len(data)), // PUSH1 size
+		0x60, 0x00, // PUSH1 0
+		0xfd, // REVERT
+	)
+}
+
+const (
+	tokenABI = `[{"type":"function","name":"balanceOf","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"},` +
+		`{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable"},` +
+		`{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"amount","type":"uint256","indexed":false}],"anonymous":false}]`
+	tokenSource = "contract Token { function balanceOf(address) external view returns (uint256) { return 1000; } }"
+)
+
+// legacyNode hides eth_getBlockReceipts, as older nodes and some providers do.
+type legacyNode struct {
+	services.ChainReader
+}
+
+type methodNotFoundError struct{}
+
+func (methodNotFoundError) Error() string {
+	return "the method eth_getBlockReceipts does not exist/is not available"
+}
+func (methodNotFoundError) ErrorCode() int { return -32601 }
+
+func (legacyNode) BlockReceipts(context.Context, rpc.BlockNumberOrHash) ([]*types.Receipt, error) {
+	return nil, methodNotFoundError{}
+}
+
+type fixture struct {
+	backend     *simulated.Backend
+	router      *gin.Engine
+	handler     *handlers.EthHandler
+	reader      services.ChainReader
+	explorer    *explorer.Client
+	ethTx       *types.Transaction
+	tokenTx     *types.Transaction
+	blockNumber uint64
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	backend := simulated.NewBackend(types.GenesisAlloc{
+		senderAddr: {Balance: senderFunds},
+		tokenAddr:  {Code: tokenRuntimeCode()},
+	})
+	t.Cleanup(func() { backend.Close() })
+
+	client := backend.Client()
+	ctx := context.Background()
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		t.Fatalf("chain id: %v", err)
+	}
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatalf("head: %v", err)
+	}
+	feeCap := new(big.Int).Add(head.BaseFee, big.NewInt(params.GWei))
+	signer := types.LatestSignerForChainID(chainID)
+
+	ethTx := types.MustSignNewTx(senderKey, signer, &types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     0,
+		GasTipCap: big.NewInt(params.GWei),
+		GasFeeCap: feeCap,
+		Gas:       21000,
+		To:        &recipientAddr,
+		Value:     transferValue,
+	})
+
+	// transfer(recipient, 1000)
+	tokenCall := append([]byte{0xa9, 0x05, 0x9c, 0xbb}, common.LeftPadBytes(recipientAddr.Bytes(), 32)...)
+	tokenCall = append(tokenCall, common.LeftPadBytes(big.NewInt(1000).Bytes(), 32)...)
+	tokenTx := types.MustSignNewTx(senderKey, signer, &types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     1,
+		GasTipCap: big.NewInt(params.GWei),
+		GasFeeCap: feeCap,
+		Gas:       100000,
+		To:        &tokenAddr,
+		Data:      tokenCall,
+	})
+
+	for _, tx := range []*types.Transaction{ethTx, tokenTx} {
+		if err := client.SendTransaction(ctx, tx); err != nil {
+			t.Fatalf("send transaction: %v", err)
+		}
+	}
+	backend.Commit()
+
+	blockNumber, err := client.BlockNumber(ctx)
+	if err != nil {
+		t.Fatalf("block number: %v", err)
+	}
+
+	// The simulated backend wraps its *ethclient.Client in a struct whose
+	// Client field hides the raw JSON-RPC client the service uses for state
+	// overrides. Unwrap it to test against the same client as production.
+	reader, ok := reflect.ValueOf(client).Field(0).Interface().(*ethclient.Client)
+	if !ok {
+		t.Fatal("simulated client does not wrap an *ethclient.Client")
+	}
+
+	explorerServer := explorertest.NewServer()
+	t.Cleanup(explorerServer.Close)
+	explorerServer.AddTransactions(senderAddr.Hex(), models.Transaction{
+		Hash:        ethTx.Hash().Hex(),
+		BlockNumber: new(big.Int).SetUint64(blockNumber).String(),
+		From:        senderAddr.Hex(),
+		To:          recipientAddr.Hex(),
+		Value:       transferValue.String(),
+	})
+	explorerServer.SetABI(tokenAddr.Hex(), tokenABI)
+	explorerServer.SetSource(tokenAddr.Hex(), tokenSource)
+	explorerClient := explorer.NewClient(explorerServer.URL, "")
+
+	service := services.NewEthServiceWithClient(reader, explorerClient)
+	trackCtx, stopTracking := context.WithCancel(ctx)
+	t.Cleanup(stopTracking)
+	service.StartGasHistory(trackCtx, 16, time.Hour)
+	// The history is backfilled in the background; wait for the genesis
+	// block and the fixture's block.
+	for deadline := time.Now().Add(10 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		history, err := service.GetGasHistory(ctx, "", "")
+		if err == nil && len(history.Points) == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("gas history was not backfilled: %+v, %v", history, err)
+		}
+	}
+	ethHandler := handlers.NewEthHandler(service)
+
+	return &fixture{
+		backend:     backend,
+		router:      newRouter(ethHandler),
+		handler:     ethHandler,
+		reader:      reader,
+		explorer:    explorerClient,
+		ethTx:       ethTx,
+		tokenTx:     tokenTx,
+		blockNumber: blockNumber,
+	}
+}
+
+func newRouter(ethHandler *handlers.EthHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api := router.Group("/api/v1")
+	api.GET("/eth/block/:number", ethHandler.GetBlock)
+	api.GET("/eth/block/:number/withdrawals", ethHandler.GetBlockWithdrawals)
+	api.GET("/eth/block/hash/:hash", ethHandler.GetBlockByHash)
+	api.GET("/eth/transaction/:hash", ethHandler.GetTransaction)
+	api.GET("/eth/transaction/:hash/receipt", ethHandler.GetTransactionReceipt)
+	api.GET("/eth/transaction/:hash/status", ethHandler.GetTransactionStatus)
+	api.GET("/eth/transaction/:hash/trace", ethHandler.TraceTransaction)
+	api.POST("/eth/transaction/send", ethHandler.SendTransaction)
+	api.GET("/eth/balance/:address", ethHandler.GetBalance)
+	api.GET("/eth/latest-block", ethHandler.GetLatestBlock)
+	api.GET("/eth/gas-price", ethHandler.GetGasPrice)
+	api.GET("/eth/gas/fees", ethHandler.GetGasFees)
+	api.GET("/eth/gas/history", ethHandler.GetGasHistory)
+	api.GET("/eth/history/:address", ethHandler.GetTransactionHistory)
+	api.GET("/eth/token-balance/:address/:tokenAddress", ethHandler.GetTokenBalance)
+	api.GET("/eth/token/:address", ethHandler.GetTokenMetadata)
+	api.GET("/eth/portfolio/:address", ethHandler.GetPortfolio)
+	api.GET("/eth/token-transfers/:address", ethHandler.GetTokenTransfers)
+	api.GET("/eth/nft/:contract/:tokenId", ethHandler.GetNFT)
+	api.GET("/eth/nft-transfers/:address", ethHandler.GetNFTTransfers)
+	api.GET("/eth/contract-abi/:address", ethHandler.GetContractABI)
+	api.GET("/eth/contract-source/:address", ethHandler.GetContractSource)
+	api.GET("/eth/event-logs/:address", ethHandler.GetEventLogs)
+	api.POST("/eth/call", ethHandler.Call)
+	api.POST("/eth/simulate", ethHandler.Simulate)
+
+	return router
+}
+
+func (f *fixture) post(t *testing.T, path, body string, wantStatus int, out interface{}) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	f.router.ServeHTTP(rec, req)
+
+	if rec.Code != wantStatus {
+		t.Fatalf("POST %s: status %d, want %d (body %s)", path, rec.Code, wantStatus, rec.Body.String())
+	}
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("POST %s: decode response: %v", path, err)
+		}
+	}
+}
+
+func (f *fixture) get(t *testing.T, path string, wantStatus int, out interface{}) {
+	t.Helper()
+	get(t, f.router, path, wantStatus, out)
+}
+
+func get(t *testing.T, router *gin.Engine, path string, wantStatus int, out interface{}) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != wantStatus {
+		t.Fatalf("GET %s: status %d, want %d (body %s)", path, rec.Code, wantStatus, rec.Body.String())
+	}
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("GET %s: decode response: %v", path, err)
+		}
+	}
+}
+
+func TestEthHandler(t *testing.T) {
+	f := newFixture(t)
+	blockNumber := new(big.Int).SetUint64(f.blockNumber).String()
+
+	t.Run("GetBlock", func(t *testing.T) {
+		var block models.Block
+		f.get(t, "/api/v1/eth/block/"+blockNumber, http.StatusOK, &block)
+
+		if block.Number != blockNumber {
+			t.Errorf("number = %s, want %s", block.Number, blockNumber)
+		}
+		if len(block.Transactions) != 2 {
+			t.Fatalf("transactions = %d, want 2", len(block.Transactions))
+		}
+		if block.Transactions[0] != f.ethTx.Hash().Hex() {
+			t.Errorf("transactions[0] = %s, want %s", block.Transactions[0], f.ethTx.Hash().Hex())
+		}
+	})
+
+	t.Run("GetBlockFull", func(t *testing.T) {
+		var block models.BlockWithTransactions
+		f.get(t, "/api/v1/eth/block/"+blockNumber+"?full=true", http.StatusOK, &block)
+		checkFullBlock(t, f, &block)
+	})
+
+	t.Run("GetBlockFullWithoutBlockReceipts", func(t *testing.T) {
+		router := newRouter(handlers.NewEthHandler(services.NewEthServiceWithClient(legacyNode{f.reader}, f.explorer)))
+
+		var block models.BlockWithTransactions
+		get(t, router, "/api/v1/eth/block/"+blockNumber+"?full=true", http.StatusOK, &block)
+		checkFullBlock(t, f, &block)
+	})
+
+	t.Run("GetBlockHeaderFields", func(t *testing.T) {
+		var block models.Block
+		f.get(t, "/api/v1/eth/block/"+blockNumber, http.StatusOK, &block)
+
+		if block.Difficulty != "" {
+			t.Errorf("difficulty = %s, want omitted after the merge", block.Difficulty)
+		}
+		if block.BaseFeePerGas == "" || block.WithdrawalsRoot == "" || block.BlobGasUsed == "" ||
+			block.ExcessBlobGas == "" || block.ParentBeaconBlockRoot == "" {
+			t.Errorf("missing fork fields: %+v", block)
+		}
+		if len(block.LogsBloom) != 2+2*256 || block.StateRoot == "" || block.ReceiptsRoot == "" {
+			t.Errorf("missing header fields: %+v", block)
+		}
+	})
+
+	t.Run("GetBlockWithdrawals", func(t *testing.T) {
+		var withdrawals models.BlockWithdrawals
+		f.get(t, "/api/v1/eth/block/"+blockNumber+"/withdrawals", http.StatusOK, &withdrawals)
+
+		if withdrawals.BlockNumber != blockNumber {
+			t.Errorf("block_number = %s, want %s", withdrawals.BlockNumber, blockNumber)
+		}
+		if withdrawals.Withdrawals == nil {
+			t.Error("withdrawals = null, want list")
+		}
+	})
+
+	t.Run("GetBlockHex", func(t *testing.T) {
+		var block models.Block
+		f.get(t, "/api/v1/eth/block/0x"+new(big.Int).SetUint64(f.blockNumber).Text(16), http.StatusOK, &block)
+
+		if block.Number != blockNumber {
+			t.Errorf("number = %s, want %s", block.Number, blockNumber)
+		}
+	})
+
+	t.Run("GetBlockInvalid", func(t *testing.T) {
+		var resp models.ErrorResponse
+		f.get(t, "/api/v1/eth/block/abc", http.StatusBadRequest, &resp)
+
+		if resp.Code != handlers.CodeInvalidInput {
+			t.Errorf("code = %s, want %s", resp.Code, handlers.CodeInvalidInput)
+		}
+	})
+
+	t.Run("GetBlockTags", func(t *testing.T) {
+		for _, tag := range []string{"latest", "safe", "finalized"} {
+			var block models.Block
+			f.get(t, "/api/v1/eth/block/"+tag, http.StatusOK, &block)
+
+			if block.Tag != tag {
+				t.Errorf("%s: tag = %q", tag, block.Tag)
+			}
+			// The simulated chain finalizes behind the head.
+			if n, ok := new(big.Int).SetString(block.Number, 10); !ok || n.Uint64() > f.blockNumber {
+				t.Errorf("%s: number = %s, want at most %s", tag, block.Number, blockNumber)
+			}
+		}
+
+		var genesis models.Block
+		f.get(t, "/api/v1/eth/block/earliest", http.StatusOK, &genesis)
+
+		if genesis.Tag != "earliest" || genesis.Number != "0" {
+			t.Errorf("earliest: tag = %q, number = %s", genesis.Tag, genesis.Number)
+		}
+	})
+
+	t.Run("GetBlockByHash", func(t *testing.T) {
+		var byNumber, byHash models.Block
+		f.get(t, "/api/v1/eth/block/"+blockNumber, http.StatusOK, &byNumber)
+		f.get(t, "/api/v1/eth/block/hash/"+byNumber.Hash, http.StatusOK, &byHash)
+
+		if byHash.Number != blockNumber || byHash.Hash != byNumber.Hash {
+			t.Errorf("block = %s (%s), want %s (%s)", byHash.Number, byHash.Hash, blockNumber, byNumber.Hash)
+		}
+		if byHash.Tag != "" {
+			t.Errorf("tag = %q, want none", byHash.Tag)
+		}
+	})
+
+	t.Run("GetBlockByHashUnknown", func(t *testing.T) {
+		f.get(t, "/api/v1/eth/block/hash/"+common.Hash{0x01}.Hex(), http.StatusNotFound, nil)
+	})
+
+	t.Run("GetBlockSingleChar", func(t *testing.T) {
+		f.get(t, "/api/v1/eth/block/a", http.StatusBadRequest, nil)
+	})
+
+	t.Run("GetBlockFuture", func(t *testing.T) {
+		f.get(t, "/api/v1/eth/block/"+new(big.Int).SetUint64(f.blockNumber+100).String(), http.StatusNotFound, nil)
+	})
+
+	t.Run("GetLatestBlock", func(t *testing.T) {
+		var block models.Block
+		f.get(t, "/api/v1/eth/latest-block", http.StatusOK, &block)
+
+		if block.Number != blockNumber {
+			t.Errorf("number = %s, want %s", block.Number, blockNumber)
+		}
+	})
+
+	t.Run("GetTransaction", func(t *testing.T) {
+		var tx models.Transaction
+		f.get(t, "/api/v1/eth/transaction/"+f.ethTx.Hash().Hex(), http.StatusOK, &tx)
+
+		if tx.From != senderAddr.Hex() {
+			t.Errorf("from = %s, want %s", tx.From, senderAddr.Hex())
+		}
+		if tx.To != recipientAddr.Hex() {
+			t.Errorf("to = %s, want %s", tx.To, recipientAddr.Hex())
+		}
+		if tx.Value != "1.000000000000000000" {
+			t.Errorf("value = %s, want 1.000000000000000000", tx.Value)
+		}
+		if tx.Status != "1" {
+			t.Errorf("status = %s, want 1", tx.Status)
+		}
+		if tx.BlockNumber != blockNumber {
+			t.Errorf("blockNumber = %s, want %s", tx.BlockNumber, blockNumber)
+		}
+		if tx.GasUsed != "21000" {
+			t.Errorf("gasUsed = %s, want 21000", tx.GasUsed)
+		}
+		if tx.Type != "2" || tx.MaxFeePerGas == "" || tx.EffectiveGasPrice == "" {
+			t.Errorf("fee fields = type %s, maxFeePerGas %q, effectiveGasPrice %q", tx.Type, tx.MaxFeePerGas, tx.EffectiveGasPrice)
+		}
+	})
+
+	t.Run("GetTransactionDecoded", func(t *testing.T) {
+		var tx models.Transaction
+		f.get(t, "/api/v1/eth/transaction/"+f.tokenTx.Hash().Hex()+"?decode=true", http.StatusOK, &tx)
+
+		decoded := tx.DecodedInput
+		if decoded == nil {
+			t.Fatal("decodedInput missing")
+		}
+		if decoded.Method != "transfer" || decoded.Signature != "transfer(address,uint256)" || decoded.Source != "explorer" {
+			t.Errorf("decodedInput = %+v", decoded)
+		}
+		if len(decoded.Arguments) != 2 || decoded.Arguments[0].Name != "to" ||
+			decoded.Arguments[0].Value != recipientAddr.Hex() || decoded.Arguments[1].Value != "1000" {
+			t.Errorf("arguments = %+v", decoded.Arguments)
+		}
+
+		var plain models.Transaction
+		f.get(t, "/api/v1/eth/transaction/"+f.ethTx.Hash().Hex()+"?decode=true", http.StatusOK, &plain)
+		if plain.DecodedInput != nil {
+			t.Errorf("decodedInput = %+v for a plain transfer", plain.DecodedInput)
+		}
+	})
+
+	t.Run("GetTransactionReceipt", func(t *testing.T) {
+		var ethReceipt, tokenReceipt models.Receipt
+		f.get(t, "/api/v1/eth/transaction/"+f.ethTx.Hash().Hex()+"/receipt", http.StatusOK, &ethReceipt)
+		f.get(t, "/api/v1/eth/transaction/"+f.tokenTx.Hash().Hex()+"/receipt", http.StatusOK, &tokenReceipt)
+
+		if ethReceipt.GasUsed != "21000" || ethReceipt.CumulativeGasUsed != "21000" || len(ethReceipt.Logs) != 0 {
+			t.Errorf("eth receipt = %+v", ethReceipt)
+		}
+		if ethReceipt.Status != "1" || ethReceipt.BlockNumber != blockNumber || ethReceipt.ContractAddress != "" {
+			t.Errorf("eth receipt = %+v", ethReceipt)
+		}
+		if len(tokenReceipt.Logs) != 1 || tokenReceipt.Logs[0].Address != tokenAddr.Hex() {
+			t.Errorf("token receipt logs = %+v", tokenReceipt.Logs)
+		}
+		if tokenReceipt.TransactionIndex != "1" {
+			t.Errorf("transactionIndex = %s, want 1", tokenReceipt.TransactionIndex)
+		}
+
+		raw, err := f.reader.TransactionReceipt(context.Background(), f.ethTx.Hash())
+		if err != nil {
+			t.Fatal(err)
+		}
+		feeWei := new(big.Int).Mul(big.NewInt(21000), raw.EffectiveGasPrice)
+		wantFee := new(big.Float).Quo(new(big.Float).SetInt(feeWei), big.NewFloat(params.Ether)).Text('f', 18)
+		if ethReceipt.Fee != wantFee {
+			t.Errorf("fee = %s, want %s", ethReceipt.Fee, wantFee)
+		}
+	})
+
+	t.Run("GetTransactionUnknown", func(t *testing.T) {
+		var resp models.ErrorResponse
+		f.get(t, "/api/v1/eth/transaction/"+common.Hash{0x01}.Hex(), http.StatusNotFound, &resp)
+
+		if resp.Code != handlers.CodeNotFound {
+			t.Errorf("code = %s, want %s", resp.Code, handlers.CodeNotFound)
+		}
+	})
+
+	t.Run("GetTransactionInvalidHash", func(t *testing.T) {
+		var resp models.ErrorResponse
+		f.get(t, "/api/v1/eth/transaction/0x1234", http.StatusBadRequest, &resp)
+
+		if resp.Field != "hash" {
+			t.Errorf("field = %s, want hash", resp.Field)
+		}
+	})
+
+	t.Run("TraceTransactionUnsupported", func(t *testing.T) {
+		// The simulated backend has no debug namespace.
+		var resp models.ErrorResponse
+		f.get(t, "/api/v1/eth/transaction/"+f.ethTx.Hash().Hex()+"/trace", http.StatusNotImplemented, &resp)
+
+		if resp.Code != handlers.CodeUnsupported {
+			t.Errorf("code = %s, want %s", resp.Code, handlers.CodeUnsupported)
+		}
+
+		f.get(t, "/api/v1/eth/transaction/0x1234/trace", http.StatusBadRequest, &resp)
+		if resp.Field != "hash" {
+			t.Errorf("field = %s, want hash", resp.Field)
+		}
+	})
+
+	t.Run("GetBalance", func(t *testing.T) {
+		var balance models.Balance
+		f.get(t, "/api/v1/eth/balance/"+recipientAddr.Hex(), http.StatusOK, &balance)
+
+		if balance.BalanceWei != transferValue.String() {
+			t.Errorf("balance_wei = %s, want %s", balance.BalanceWei, transferValue)
+		}
+		if balance.Balance != "1.000000000000000000" {
+			t.Errorf("balance = %s, want 1.000000000000000000", balance.Balance)
+		}
+	})
+
+	t.Run("GetBalanceAtBlock", func(t *testing.T) {
+		genesis, err := f.reader.HeaderByNumber(context.Background(), big.NewInt(0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		balanceURL := "/api/v1/eth/balance/" + recipientAddr.Hex()
+		before := strconv.FormatUint(f.blockNumber-1, 10)
+
+		for query, want := range map[string]string{
+			"?block=" + before:               "0",
+			"?block=0x0":                     "0",
+			"?block=" + genesis.Hash().Hex(): "0",
+			"?block=latest":                  transferValue.String(),
+			"?block=" + strconv.FormatUint(f.blockNumber, 10): transferValue.String(),
+		} {
+			var balance models.Balance
+			f.get(t, balanceURL+query, http.StatusOK, &balance)
+			if balance.BalanceWei != want {
+				t.Errorf("%s: balance_wei = %s, want %s", query, balance.BalanceWei, want)
+			}
+		}
+
+		var resp models.ErrorResponse
+		f.get(t, balanceURL+"?block=1000", http.StatusNotFound, &resp)
+		if resp.Code != handlers.CodeNotFound {
+			t.Errorf("code = %s, want %s", resp.Code, handlers.CodeNotFound)
+		}
+
+		f.get(t, balanceURL+"?block=yesterday", http.StatusBadRequest, &resp)
+		if resp.Field != "block" {
+			t.Errorf("field = %s, want block", resp.Field)
+		}
+		f.get(t, "/api/v1/eth/token-balance/"+recipientAddr.Hex()+"/"+tokenAddr.Hex()+"?block=-1", http.StatusBadRequest, &resp)
+		if resp.Field != "block" {
+			t.Errorf("field = %s, want block", resp.Field)
+		}
+	})
+
+	t.Run("GetBalanceInvalidAddress", func(t *testing.T) {
+		var resp models.ErrorResponse
+		f.get(t, "/api/v1/eth/balance/foo", http.StatusBadRequest, &resp)
+
+		if resp.Code != handlers.CodeInvalidInput || resp.Field != "address" {
+			t.Errorf("response = %+v, want invalid address", resp)
+		}
+	})
+
+	t.Run("GetTokenBalanceInvalidToken", func(t *testing.T) {
+		var resp models.ErrorResponse
+		f.get(t, "/api/v1/eth/token-balance/"+recipientAddr.Hex()+"/0x1234", http.StatusBadRequest, &resp)
+
+		if resp.Field != "tokenAddress" {
+			t.Errorf("field = %s, want tokenAddress", resp.Field)
+		}
+	})
+
+	t.Run("GetGasPrice", func(t *testing.T) {
+		var gasPrice models.GasPrice
+		f.get(t, "/api/v1/eth/gas-price", http.StatusOK, &gasPrice)
+
+		wei, ok := new(big.Int).SetString(gasPrice.GasPriceWei, 10)
+		if !ok || wei.Sign() <= 0 {
+			t.Errorf("gas_price_wei = %q, want positive integer", gasPrice.GasPriceWei)
+		}
+	})
+
+	t.Run("GetGasFees", func(t *testing.T) {
+		var fees models.GasFees
+		f.get(t, "/api/v1/eth/gas/fees", http.StatusOK, &fees)
+
+		if fees.BlockNumber != f.blockNumber || fees.Blocks < 1 {
+			t.Errorf("window = %d blocks up to %d, want up to %d", fees.Blocks, fees.BlockNumber, f.blockNumber)
+		}
+		// Both fixture transactions paid a 1 gwei tip.
+		if fees.Standard.MaxPriorityFeePerGasWei != strconv.Itoa(params.GWei) {
+			t.Errorf("standard tip = %s, want 1 gwei", fees.Standard.MaxPriorityFeePerGasWei)
+		}
+		next, _ := new(big.Int).SetString(fees.NextBaseFeeWei, 10)
+		maxFee, _ := new(big.Int).SetString(fees.Fast.MaxFeePerGasWei, 10)
+		if next == nil || next.Sign() <= 0 || maxFee == nil || maxFee.Cmp(next) <= 0 {
+			t.Errorf("next_base_fee_wei = %s, fast max_fee_per_gas_wei = %s", fees.NextBaseFeeWei, fees.Fast.MaxFeePerGasWei)
+		}
+		if fees.BlobBaseFeeWei == "" {
+			t.Error("blob_base_fee_wei missing")
+		}
+
+		var resp models.ErrorResponse
+		f.get(t, "/api/v1/eth/gas/fees?blocks=0", http.StatusBadRequest, &resp)
+		if resp.Field != "blocks" {
+			t.Errorf("field = %s, want blocks", resp.Field)
+		}
+	})
+
+	t.Run("GetGasHistory", func(t *testing.T) {
+		var history models.GasHistory
+		f.get(t, "/api/v1/eth/gas/history", http.StatusOK, &history)
+
+		// The genesis block and the fixture's block.
+		if len(history.Points) != 2 {
+			t.Fatalf("points = %+v, want 2", history.Points)
+		}
+		point := history.Points[1]
+		if point.FromBlock != f.blockNumber || point.ToBlock != f.blockNumber || point.GasUsedRatio <= 0 {
+			t.Errorf("point = %+v", point)
+		}
+		// Both fixture transactions paid a 1 gwei tip.
+		if len(point.PriorityFeesWei) != len(history.Percentiles) || point.PriorityFeesWei[2] != strconv.Itoa(params.GWei) {
+			t.Errorf("priority_fees_wei = %v, want a 1 gwei median", point.PriorityFeesWei)
+		}
+
+		f.get(t, "/api/v1/eth/gas/history?bucket=hour", http.StatusOK, &history)
+		blocks := 0
+		for _, point := range history.Points {
+			blocks += point.Blocks
+		}
+		if history.Bucket != "hour" || blocks != 2 {
+			t.Errorf("bucketed history = %+v", history)
+		}
+
+		var resp models.ErrorResponse
+		f.get(t, "/api/v1/eth/gas/history?bucket=day", http.StatusBadRequest, &resp)
+		if resp.Field != "bucket" {
+			t.Errorf("field = %s, want bucket", resp.Field)
+		}
+	})
+
+	t.Run("GetTokenBalance", func(t *testing.T) {
+		var balance models.TokenBalance
+		f.get(t, "/api/v1/eth/token-balance/"+recipientAddr.Hex()+"/"+tokenAddr.Hex(), http.StatusOK, &balance)
+
+		if balance.Balance != "1000" {
+			t.Errorf("balance = %s, want 1000", balance.Balance)
+		}
+		if balance.Symbol != "TKN" || balance.Decimals == nil || *balance.Decimals != 2 || balance.FormattedBalance != "10.00" {
+			t.Errorf("balance = %+v, want 10.00 TKN", balance)
+		}
+	})
+
+	t.Run("GetPortfolio", func(t *testing.T) {
+		// The simulated chain has no Multicall3, so the balances are read
+		// one call at a time.
+		var portfolio models.Portfolio
+		f.get(t, "/api/v1/eth/portfolio/"+recipientAddr.Hex()+"?tokens="+tokenAddr.Hex()+","+senderAddr.Hex(), http.StatusOK, &portfolio)
+
+		if portfolio.BalanceWei != transferValue.String() {
+			t.Errorf("balance_wei = %s, want %s", portfolio.BalanceWei, transferValue)
+		}
+		if len(portfolio.Tokens) != 1 || portfolio.Tokens[0].Symbol != "TKN" || portfolio.Tokens[0].FormattedBalance != "10.00" {
+			t.Errorf("tokens = %+v", portfolio.Tokens)
+		}
+		// The sender is not a contract, so its balanceOf returns no data.
+		if len(portfolio.FailedTokens) != 1 || portfolio.FailedTokens[0] != senderAddr.Hex() {
+			t.Errorf("failed_tokens = %v", portfolio.FailedTokens)
+		}
+
+		var resp models.ErrorResponse
+		f.get(t, "/api/v1/eth/portfolio/"+recipientAddr.Hex(), http.StatusBadRequest, &resp)
+		if resp.Field != "tokens" {
+			t.Errorf("field = %s, want tokens", resp.Field)
+		}
+	})
+
+	t.Run("GetTokenMetadata", func(t *testing.T) {
+		var metadata models.TokenMetadata
+		f.get(t, "/api/v1/eth/token/"+tokenAddr.Hex(), http.StatusOK, &metadata)
+
+		if metadata.Name != "" || metadata.Symbol != "TKN" || metadata.Decimals == nil || *metadata.Decimals != 2 {
+			t.Errorf("metadata = %+v", metadata)
+		}
+		if metadata.TotalSupply != "1000" || metadata.FormattedTotalSupply != "10.00" {
+			t.Errorf("total supply = %s (%s)", metadata.TotalSupply, metadata.FormattedTotalSupply)
+		}
+
+		var resp models.ErrorResponse
+		f.get(t, "/api/v1/eth/token/"+recipientAddr.Hex(), http.StatusNotFound, &resp)
+		if resp.Code != handlers.CodeNotFound {
+			t.Errorf("code = %s, want %s", resp.Code, handlers.CodeNotFound)
+		}
+	})
+
+	t.Run("GetTokenTransfers", func(t *testing.T) {
+		var page models.TokenTransferPage
+		f.get(t, "/api/v1/eth/token-transfers/"+recipientAddr.Hex(), http.StatusOK, &page)
+
+		if len(page.Transfers) != 1 || page.NextCursor != "" {
+			t.Fatalf("transfers = %d, want 1", len(page.Transfers))
+		}
+		got := page.Transfers[0]
+		if got.TokenAddress != tokenAddr.Hex() || got.From != senderAddr.Hex() || got.To != recipientAddr.Hex() {
+			t.Errorf("transfer = %+v", got)
+		}
+		if got.Value != "1000" || got.FormattedValue != "10.00" || got.TokenSymbol != "TKN" || got.Direction != "in" {
+			t.Errorf("value = %s (%s %s), direction = %s", got.Value, got.FormattedValue, got.TokenSymbol, got.Direction)
+		}
+		if got.TxHash != f.tokenTx.Hash().Hex() {
+			t.Errorf("tx_hash = %s, want %s", got.TxHash, f.tokenTx.Hash().Hex())
+		}
+
+		var block models.Block
+		f.get(t, "/api/v1/eth/block/"+strconv.FormatUint(f.blockNumber, 10), http.StatusOK, &block)
+		if got.BlockNumber != f.blockNumber || got.LogIndex != 0 || !got.Timestamp.Equal(block.Timestamp) {
+			t.Errorf("position = block %d, log %d, timestamp %s; block timestamp %s", got.BlockNumber, got.LogIndex, got.Timestamp, block.Timestamp)
+		}
+	})
+
+	t.Run("GetTokenTransfersFilters", func(t *testing.T) {
+		transfersURL := "/api/v1/eth/token-transfers/" + senderAddr.Hex()
+		block := strconv.FormatUint(f.blockNumber, 10)
+
+		for query, want := range map[string]int{
+			"":                              1,
+			"?direction=out":                1,
+			"?direction=in":                 0,
+			"?token=" + tokenAddr.Hex():     1,
+			"?token=" + recipientAddr.Hex(): 0,
+			"?fromBlock=" + block + "&toBlock=" + block:                       1,
+			"?fromBlock=0&toBlock=" + strconv.FormatUint(f.blockNumber-1, 10): 0,
+		} {
+			var page models.TokenTransferPage
+			f.get(t, transfersURL+query, http.StatusOK, &page)
+			if len(page.Transfers) != want {
+				t.Errorf("%q: transfers = %d, want %d", query, len(page.Transfers), want)
+			}
+			if want == 1 && page.Transfers[0].Direction != "out" {
+				t.Errorf("%q: direction = %s, want out", query, page.Transfers[0].Direction)
+			}
+		}
+
+		for query, field := range map[string]string{
+			"?direction=sideways": "direction",
+			"?token=0x1234":       "token",
+			"?limit=5000":         "limit",
+		} {
+			var resp models.ErrorResponse
+			f.get(t, transfersURL+query, http.StatusBadRequest, &resp)
+			if resp.Field != field {
+				t.Errorf("%s: field = %s, want %s", query, resp.Field, field)
+			}
+		}
+	})
+
+	t.Run("GetNFTTransfers", func(t *testing.T) {
+		// The ERC-20 Transfer log has no token ID topic.
+		var page models.NFTTransferPage
+		f.get(t, "/api/v1/eth/nft-transfers/"+senderAddr.Hex(), http.StatusOK, &page)
+		if page.Transfers == nil || len(page.Transfers) != 0 {
+			t.Errorf("transfers = %+v, want an empty list", page.Transfers)
+		}
+
+		var resp models.ErrorResponse
+		f.get(t, "/api/v1/eth/nft-transfers/"+senderAddr.Hex()+"?direction=sideways", http.StatusBadRequest, &resp)
+		if resp.Field != "direction" {
+			t.Errorf("field = %s, want direction", resp.Field)
+		}
+	})
+
+	t.Run("GetNFT", func(t *testing.T) {
+		var resp models.ErrorResponse
+		f.get(t, "/api/v1/eth/nft/"+recipientAddr.Hex()+"/1", http.StatusNotFound, &resp)
+		if resp.Code != handlers.CodeNotFound {
+			t.Errorf("code = %s, want %s", resp.Code, handlers.CodeNotFound)
+		}
+
+		for path, field := range map[string]string{
+			"/api/v1/eth/nft/" + tokenAddr.Hex() + "/abc": "tokenId",
+			"/api/v1/eth/nft/" + tokenAddr.Hex() + "/-1":  "tokenId",
+			"/api/v1/eth/nft/0x1234/1":                    "contract",
+		} {
+			var resp models.ErrorResponse
+			f.get(t, path, http.StatusBadRequest, &resp)
+			if resp.Field != field {
+				t.Errorf("%s: field = %s, want %s", path, resp.Field, field)
+			}
+		}
+	})
+
+	t.Run("Call", func(t *testing.T) {
+		var result models.CallResult
+		f.post(t, "/api/v1/eth/call", `{"to":"`+tokenAddr.Hex()+`","function":"balanceOf(address owner) view returns (uint256)","args":["`+recipientAddr.Hex()+`"]}`, http.StatusOK, &result)
+		if !result.Success || result.Function != "balanceOf(address)" || len(result.Outputs) != 1 || result.Outputs[0].Value != "1000" {
+			t.Errorf("result = %+v", result)
+		}
+
+		// An ABI fragment, at a past block.
+		fragment := `{"type":"function","name":"decimals","inputs":[],"outputs":[{"name":"","type":"uint8"}],"stateMutability":"view"}`
+		f.post(t, "/api/v1/eth/call", `{"to":"`+tokenAddr.Hex()+`","abi":`+fragment+`,"block":"0"}`, http.StatusOK, &result)
+		if !result.Success || len(result.Outputs) != 1 || result.Outputs[0].Value != "2" {
+			t.Errorf("result = %+v", result)
+		}
+	})
+
+	t.Run("CallRevert", func(t *testing.T) {
+		reason, err := abi.Arguments{{Type: mustType(t, "string")}}.Pack("nope")
+		if err != nil {
+			t.Fatal(err)
+		}
+		errorData := append([]byte{0x08, 0xc3, 0x79, 0xa0}, reason...)
+		customData := append(crypto.Keccak256([]byte("Unauthorized(address)"))[:4], common.LeftPadBytes(senderAddr.Bytes(), 32)...)
+
+		// Code overrides make recipientAddr revert.
+		override := func(data []byte) string {
+			return `"state_overrides":{"` + recipientAddr.Hex() + `":{"code":"` + hexutil.Encode(revertCode(data)) + `"}}`
+		}
+
+		var result models.CallResult
+		f.post(t, "/api/v1/eth/call", `{"to":"`+recipientAddr.Hex()+`","function":"owner()(address)",`+override(errorData)+`}`, http.StatusOK, &result)
+		if result.Success || result.Revert == nil || result.Revert.Kind != models.RevertError || result.Revert.Reason != "nope" {
+			t.Errorf("result = %+v, revert = %+v", result, result.Revert)
+		}
+
+		fragment := `[{"type":"function","name":"owner","inputs":[],"outputs":[{"name":"","type":"address"}]},` +
+			`{"type":"error","name":"Unauthorized","inputs":[{"name":"caller","type":"address"}]}]`
+		f.post(t, "/api/v1/eth/call", `{"to":"`+recipientAddr.Hex()+`","abi":`+fragment+`,`+override(customData)+`}`, http.StatusOK, &result)
+		if result.Revert == nil || result.Revert.Kind != models.RevertCustom || result.Revert.Name != "Unauthorized" || result.Revert.Arguments[0].Value != senderAddr.Hex() {
+			t.Errorf("revert = %+v", result.Revert)
+		}
+	})
+
+	t.Run("CallInvalid", func(t *testing.T) {
+		for body, field := range map[string]string{
+			`{"to":"` + tokenAddr.Hex() + `"}`:                                                    "function",
+			`{"to":"0x1234","function":"decimals()"}`:                                             "to",
+			`{"to":"` + tokenAddr.Hex() + `","function":"balanceOf(address)","args":[]}`:          "args",
+			`{"to":"` + tokenAddr.Hex() + `","function":"balanceOf(address)","args":["0x12"]}`:    "args",
+			`{"to":"` + tokenAddr.Hex() + `","function":"decimals()","block":"soon"}`:             "block",
+			`{"to":"` + tokenAddr.Hex() + `","abi":[{"type":"event","name":"E","inputs":[]}]}`:    "abi",
+			`{"to":"` + tokenAddr.Hex() + `","function":"decimals()","state_overrides":{"x":{}}}`: "state_overrides",
+		} {
+			var resp models.ErrorResponse
+			f.post(t, "/api/v1/eth/call", body, http.StatusBadRequest, &resp)
+			if resp.Field != field {
+				t.Errorf("%s: field = %q, want %s", body, resp.Field, field)
+			}
+		}
+
+		var resp models.ErrorResponse
+		f.post(t, "/api/v1/eth/call", `{"to":`, http.StatusBadRequest, &resp)
+		if resp.Code != handlers.CodeInvalidInput {
+			t.Errorf("code = %s, want %s", resp.Code, handlers.CodeInvalidInput)
+		}
+	})
+
+	t.Run("Simulate", func(t *testing.T) {
+		// transfer(recipient, 1000)
+		data := "0xa9059cbb" + strings.Repeat("0", 24) + recipientAddr.Hex()[2:] + strings.Repeat("0", 61) + "3e8"
+		var sim models.Simulation
+		f.post(t, "/api/v1/eth/simulate", `{"from":"`+senderAddr.Hex()+`","to":"`+tokenAddr.Hex()+`","data":"`+data+`"}`, http.StatusOK, &sim)
+		if !sim.Success || sim.GasEstimate <= 21000 || sim.GasUsed == 0 || sim.ReturnData != hexutil.Encode(common.LeftPadBytes([]byte{0x03, 0xe8}, 32)) {
+			t.Errorf("simulation = %+v", sim)
+		}
+		if len(sim.Logs) != 1 || sim.Logs[0].Decoded == nil || sim.Logs[0].Decoded.Event != "Transfer" {
+			t.Fatalf("logs = %+v", sim.Logs)
+		}
+		if sim.Logs[0].Decoded.Parameters[1].Value != recipientAddr.Hex() {
+			t.Errorf("decoded transfer = %+v", sim.Logs[0].Decoded)
+		}
+
+		// A plain transfer from the genesis block, before the sender spent
+		// anything.
+		var transfer models.Simulation
+		f.post(t, "/api/v1/eth/simulate", `{"from":"`+senderAddr.Hex()+`","to":"`+recipientAddr.Hex()+`","value":"1000","block":"0"}`, http.StatusOK, &transfer)
+		if !transfer.Success || transfer.GasEstimate != 21000 || transfer.Logs == nil || len(transfer.Logs) != 0 {
+			t.Errorf("transfer simulation = %+v", transfer)
+		}
+	})
+
+	t.Run("SimulateFailure", func(t *testing.T) {
+		reason, err := abi.Arguments{{Type: mustType(t, "string")}}.Pack("nope")
+		if err != nil {
+			t.Fatal(err)
+		}
+		code := revertCode(append([]byte{0x08, 0xc3, 0x79, 0xa0}, reason...))
+
+		var sim models.Simulation
+		f.post(t, "/api/v1/eth/simulate", `{"from":"`+senderAddr.Hex()+`","to":"`+recipientAddr.Hex()+`",`+
+			`"state_overrides":{"`+recipientAddr.Hex()+`":{"code":"`+hexutil.Encode(code)+`"}}}`, http.StatusOK, &sim)
+		if sim.Success || sim.GasEstimate != 0 || sim.Revert == nil || sim.Revert.Reason != "nope" {
+			t.Errorf("simulation = %+v, revert = %+v", sim, sim.Revert)
+		}
+
+		// recipientAddr holds just the fixture's transfer.
+		var broke models.Simulation
+		f.post(t, "/api/v1/eth/simulate", `{"from":"`+recipientAddr.Hex()+`","to":"`+senderAddr.Hex()+`","value":"`+senderFunds.String()+`"}`, http.StatusOK, &broke)
+		if broke.Success || broke.Revert != nil || !strings.Contains(broke.Error, "insufficient funds") {
+			t.Errorf("simulation = %+v", broke)
+		}
+
+		for body, field := range map[string]string{
+			`{"to":"` + tokenAddr.Hex() + `","data":"0xzz"}`: "data",
+			`{"to":"` + tokenAddr.Hex() + `","gas":"0"}`:     "gas",
+			`{"value":"1"}`: "to",
+		} {
+			var resp models.ErrorResponse
+			f.post(t, "/api/v1/eth/simulate", body, http.StatusBadRequest, &resp)
+			if resp.Field != field {
+				t.Errorf("%s: field = %q, want %s", body, resp.Field, field)
+			}
+		}
+	})
+
+	t.Run("GetEventLogs", func(t *testing.T) {
+		transferTopic := crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+		var page models.EventLogPage
+		f.get(t, "/api/v1/eth/event-logs/"+tokenAddr.Hex()+"?topics="+transferTopic.Hex(), http.StatusOK, &page)
+
+		logs := page.Logs
+		if len(logs) != 1 || page.NextCursor != "" {
+			t.Fatalf("logs = %d, want 1", len(logs))
+		}
+		if logs[0].BlockNumber != f.blockNumber {
+			t.Errorf("block_number = %d, want %d", logs[0].BlockNumber, f.blockNumber)
+		}
+		if len(logs[0].Topics) != 3 || logs[0].Topics[0] != transferTopic.Hex() {
+			t.Errorf("topics = %v", logs[0].Topics)
+		}
+	})
+
+	t.Run("GetEventLogsRange", func(t *testing.T) {
+		logsURL := "/api/v1/eth/event-logs/" + tokenAddr.Hex()
+		block := strconv.FormatUint(f.blockNumber, 10)
+		before := strconv.FormatUint(f.blockNumber-1, 10)
+
+		var page models.EventLogPage
+		f.get(t, logsURL+"?fromBlock="+block+"&toBlock="+block, http.StatusOK, &page)
+		if len(page.Logs) != 1 {
+			t.Errorf("logs in block %s = %d, want 1", block, len(page.Logs))
+		}
+
+		page = models.EventLogPage{}
+		f.get(t, logsURL+"?fromBlock=0&toBlock="+before, http.StatusOK, &page)
+		if page.Logs == nil || len(page.Logs) != 0 {
+			t.Errorf("logs before block %s = %+v, want []", block, page.Logs)
+		}
+
+		receipt, err := f.reader.TransactionReceipt(context.Background(), f.tokenTx.Hash())
+		if err != nil {
+			t.Fatal(err)
+		}
+		page = models.EventLogPage{}
+		f.get(t, logsURL+"?blockHash="+receipt.BlockHash.Hex(), http.StatusOK, &page)
+		if len(page.Logs) != 1 {
+			t.Errorf("logs by block hash = %d, want 1", len(page.Logs))
+		}
+
+		for query, field := range map[string]string{
+			"?limit=0":      "limit",
+			"?cursor=bogus": "cursor",
+			"?fromBlock=" + block + "&toBlock=" + before:            "fromBlock",
+			"?blockHash=" + common.Hash{}.Hex() + "&toBlock=latest": "blockHash",
+		} {
+			var resp models.ErrorResponse
+			f.get(t, logsURL+query, http.StatusBadRequest, &resp)
+			if resp.Field != field {
+				t.Errorf("%s: field = %s, want %s", query, resp.Field, field)
+			}
+		}
+	})
+
+	t.Run("GetEventLogsTopicFilters", func(t *testing.T) {
+		transferTopic := crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)")).Hex()
+		approvalTopic := crypto.Keccak256Hash([]byte("Approval(address,address,uint256)")).Hex()
+		recipientTopic := common.BytesToHash(recipientAddr.Bytes()).Hex()
+		logsURL := "/api/v1/eth/event-logs/" + tokenAddr.Hex()
+
+		for query, want := range map[string]int{
+			"?topic0=" + transferTopic + "," + approvalTopic:         1,
+			"?topic0=" + approvalTopic + "&topic0=" + transferTopic:  1,
+			"?topic0=" + approvalTopic:                               0,
+			"?topic2=" + recipientTopic:                              1,
+			"?topic1=" + recipientTopic:                              0,
+			"?topic0=" + transferTopic + "&topic2=" + recipientTopic: 1,
+		} {
+			var page models.EventLogPage
+			f.get(t, logsURL+query, http.StatusOK, &page)
+			if len(page.Logs) != want {
+				t.Errorf("%s: logs = %d, want %d", query, len(page.Logs), want)
+			}
+		}
+
+		var page models.EventLogPage
+		f.get(t, "/api/v1/eth/event-logs/"+recipientAddr.Hex()+","+tokenAddr.Hex(), http.StatusOK, &page)
+		if len(page.Logs) != 1 || page.Logs[0].Address != tokenAddr.Hex() {
+			t.Errorf("logs of several addresses = %+v", page.Logs)
+		}
+
+		var resp models.ErrorResponse
+		f.get(t, logsURL+"?topics="+transferTopic+"&topic1="+recipientTopic, http.StatusBadRequest, &resp)
+		if resp.Field != "topic1" {
+			t.Errorf("field = %s, want topic1", resp.Field)
+		}
+		f.get(t, logsURL+"?topic3=0x1234", http.StatusBadRequest, &resp)
+		if resp.Field != "topic3" {
+			t.Errorf("field = %s, want topic3", resp.Field)
+		}
+	})
+
+	t.Run("GetEventLogsDecoded", func(t *testing.T) {
+		var page models.EventLogPage
+		f.get(t, "/api/v1/eth/event-logs/"+tokenAddr.Hex()+"?decode=true", http.StatusOK, &page)
+
+		logs := page.Logs
+		if len(logs) != 1 || logs[0].Decoded == nil {
+			t.Fatalf("logs = %+v", logs)
+		}
+		decoded := logs[0].Decoded
+		if decoded.Status != models.EventDecoded || decoded.Event != "Transfer" || decoded.Source != "explorer" {
+			t.Errorf("decoded = %+v", decoded)
+		}
+		params := decoded.Parameters
+		if len(params) != 3 || params[0].Value != senderAddr.Hex() || !params[1].Indexed ||
+			params[1].Value != recipientAddr.Hex() || params[2].Name != "amount" || params[2].Value != "1000" {
+			t.Errorf("parameters = %+v", params)
+		}
+	})
+
+	t.Run("GetTransactionHistory", func(t *testing.T) {
+		var history models.TransactionHistory
+		f.get(t, "/api/v1/eth/history/"+senderAddr.Hex(), http.StatusOK, &history)
+
+		if len(history.Transactions) != 1 || history.Transactions[0].Hash != f.ethTx.Hash().Hex() {
+			t.Errorf("transactions = %+v", history.Transactions)
+		}
+	})
+
+	t.Run("GetTransactionHistoryEmpty", func(t *testing.T) {
+		var history models.TransactionHistory
+		f.get(t, "/api/v1/eth/history/"+tokenAddr.Hex(), http.StatusOK, &history)
+
+		if len(history.Transactions) != 0 {
+			t.Errorf("transactions = %+v, want none", history.Transactions)
+		}
+	})
+
+	t.Run("GetContractABI", func(t *testing.T) {
+		var abi models.ContractABI
+		f.get(t, "/api/v1/eth/contract-abi/"+tokenAddr.Hex(), http.StatusOK, &abi)
+
+		if abi.ABI != tokenABI {
+			t.Errorf("abi = %s", abi.ABI)
+		}
+	})
+
+	t.Run("GetContractABIUnverified", func(t *testing.T) {
+		f.get(t, "/api/v1/eth/contract-abi/"+recipientAddr.Hex(), http.StatusNotFound, nil)
+	})
+
+	t.Run("GetContractSource", func(t *testing.T) {
+		var source models.ContractSource
+		f.get(t, "/api/v1/eth/contract-source/"+tokenAddr.Hex(), http.StatusOK, &source)
+
+		if source.SourceCode != tokenSource {
+			t.Errorf("source_code = %s", source.SourceCode)
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		router := gin.New()
+		router.GET("/block/:number", handlers.Timeout(time.Nanosecond), func(c *gin.Context) {
+			time.Sleep(time.Millisecond)
+			f.handler.GetBlock(c)
+		})
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/block/"+blockNumber, nil))
+
+		if rec.Code != http.StatusGatewayTimeout {
+			t.Errorf("status = %d, want %d (body %s)", rec.Code, http.StatusGatewayTimeout, rec.Body.String())
+		}
+	})
+}
+
+// TestSendTransaction has its own chain, as it mines a block.
+func TestSendTransaction(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	chainID, err := f.reader.ChainID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := f.reader.HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(chainID *big.Int, nonce uint64, tip int64, value *big.Int) string {
+		tx := types.MustSignNewTx(senderKey, types.LatestSignerForChainID(chainID), &types.DynamicFeeTx{
+			ChainID:   chainID,
+			Nonce:     nonce,
+			GasTipCap: big.NewInt(tip),
+			GasFeeCap: new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), big.NewInt(tip)),
+			Gas:       21000,
+			To:        &recipientAddr,
+			Value:     value,
+		})
+		raw, err := tx.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hexutil.Encode(raw)
+	}
+	send := func(raw string, wantStatus int, out interface{}) {
+		t.Helper()
+		f.post(t, "/api/v1/eth/transaction/send", `{"raw_transaction":"`+raw+`"}`, wantStatus, out)
+	}
+
+	var sent models.TransactionStatus
+	send(sign(chainID, 2, params.GWei, big.NewInt(1)), http.StatusAccepted, &sent)
+	if sent.Status != models.TxPending || sent.From != senderAddr.Hex() || sent.Nonce != 2 {
+		t.Errorf("sent = %+v", sent)
+	}
+	var status models.TransactionStatus
+	f.get(t, "/api/v1/eth/transaction/"+sent.Hash+"/status", http.StatusOK, &status)
+	if status.Status != models.TxPending || status.From != senderAddr.Hex() {
+		t.Errorf("status = %+v", status)
+	}
+
+	// A higher tip replaces the pending transaction.
+	var replacement models.TransactionStatus
+	send(sign(chainID, 2, 2*params.GWei, big.NewInt(1)), http.StatusAccepted, &replacement)
+	f.get(t, "/api/v1/eth/transaction/"+sent.Hash+"/status", http.StatusOK, &status)
+	if status.Status != models.TxReplaced || status.Nonce != 2 {
+		t.Errorf("replaced status = %+v", status)
+	}
+
+	f.backend.Commit()
+	var included models.TransactionStatus
+	f.get(t, "/api/v1/eth/transaction/"+replacement.Hash+"/status", http.StatusOK, &included)
+	if included.Status != models.TxIncluded && included.Status != models.TxFinalized {
+		t.Errorf("status = %s, want included", included.Status)
+	}
+	if included.BlockNumber != f.blockNumber+1 || included.Confirmations != 1 || included.Success == nil || !*included.Success {
+		t.Errorf("included = %+v", included)
+	}
+
+	for name, raw := range map[string]string{
+		"used nonce":         sign(chainID, 0, params.GWei, big.NewInt(1)),
+		"nonce gap":          sign(chainID, 9, params.GWei, big.NewInt(1)),
+		"other chain":        sign(new(big.Int).Add(chainID, big.NewInt(1)), 3, params.GWei, big.NewInt(1)),
+		"insufficient funds": sign(chainID, 3, params.GWei, senderFunds),
+		"not hex":            "0xzz",
+		"not a transaction":  "0x1234",
+	} {
+		var resp models.ErrorResponse
+		send(raw, http.StatusBadRequest, &resp)
+		if resp.Field != "raw_transaction" {
+			t.Errorf("%s: field = %q, want raw_transaction (%s)", name, resp.Field, resp.Message)
+		}
+	}
+
+	var resp models.ErrorResponse
+	f.get(t, "/api/v1/eth/transaction/0x"+strings.Repeat("ab", 32)+"/status", http.StatusNotFound, &resp)
+}
+
+func checkFullBlock(t *testing.T, f *fixture, block *models.BlockWithTransactions) {
+	t.Helper()
+
+	if block.Number != new(big.Int).SetUint64(f.blockNumber).String() {
+		t.Errorf("number = %s, want %d", block.Number, f.blockNumber)
+	}
+	if len(block.Transactions) != 2 {
+		t.Fatalf("transactions = %d, want 2", len(block.Transactions))
+	}
+	for i, want := range []*types.Transaction{f.ethTx, f.tokenTx} {
+		got := block.Transactions[i]
+		if got.Hash != want.Hash().Hex() || got.From != senderAddr.Hex() || got.Status != "1" {
+			t.Errorf("transactions[%d] = %+v", i, got)
+		}
+		if got.BlockHash != block.Hash || got.TransactionIndex != strconv.Itoa(i) {
+			t.Errorf("transactions[%d] position = %s/%s", i, got.BlockHash, got.TransactionIndex)
+		}
+	}
+}
+
+func mustType(t *testing.T, name string) abi.Type {
+	t.Helper()
+	typ, err := abi.NewType(name, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return typ
+}
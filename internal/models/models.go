@@ -1,36 +1,144 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
 type Block struct {
-	Number       string    `json:"number"`
-	Hash         string    `json:"hash"`
-	ParentHash   string    `json:"parent_hash"`
-	Timestamp    time.Time `json:"timestamp"`
-	Miner        string    `json:"miner"`
-	GasLimit     string    `json:"gas_limit"`
-	GasUsed      string    `json:"gas_used"`
-	Difficulty   string    `json:"difficulty"`
-	Size         string    `json:"size"`
-	Transactions []string  `json:"transactions"`
+	Tag                   string       `json:"tag,omitempty"`
+	Number                string       `json:"number"`
+	Hash                  string       `json:"hash"`
+	ParentHash            string       `json:"parent_hash"`
+	Timestamp             time.Time    `json:"timestamp"`
+	Miner                 string       `json:"miner"`
+	GasLimit              string       `json:"gas_limit"`
+	GasUsed               string       `json:"gas_used"`
+	Difficulty            string       `json:"difficulty,omitempty"`
+	Size                  string       `json:"size"`
+	StateRoot             string       `json:"state_root"`
+	ReceiptsRoot          string       `json:"receipts_root"`
+	LogsBloom             string       `json:"logs_bloom"`
+	ExtraData             string       `json:"extra_data"`
+	MixHash               string       `json:"mix_hash"`
+	Nonce                 string       `json:"nonce"`
+	BaseFeePerGas         string       `json:"base_fee_per_gas,omitempty"`
+	WithdrawalsRoot       string       `json:"withdrawals_root,omitempty"`
+	Withdrawals           []Withdrawal `json:"withdrawals,omitempty"`
+	BlobGasUsed           string       `json:"blob_gas_used,omitempty"`
+	ExcessBlobGas         string       `json:"excess_blob_gas,omitempty"`
+	ParentBeaconBlockRoot string       `json:"parent_beacon_block_root,omitempty"`
+	Transactions          []string     `json:"transactions"`
+}
+
+// BlockWithTransactions is a Block whose transactions are full objects
+// rather than hashes.
+type BlockWithTransactions struct {
+	Block
+	Transactions []Transaction `json:"transactions"`
+}
+
+// Withdrawal is a beacon chain withdrawal credited in an execution block.
+type Withdrawal struct {
+	Index          string `json:"index"`
+	ValidatorIndex string `json:"validator_index"`
+	Address        string `json:"address"`
+	AmountGwei     string `json:"amount_gwei"`
+}
+
+type BlockWithdrawals struct {
+	BlockNumber string       `json:"block_number"`
+	BlockHash   string       `json:"block_hash"`
+	Withdrawals []Withdrawal `json:"withdrawals"`
 }
 
 type Transaction struct {
-	Hash             string `json:"hash"`
-	BlockNumber      string `json:"blockNumber"`
-	BlockHash        string `json:"blockHash"`
-	TransactionIndex string `json:"transactionIndex"`
-	From             string `json:"from"`
-	To               string `json:"to"`
-	Value            string `json:"value"`
-	Gas              string `json:"gas"`
-	GasPrice         string `json:"gasPrice"`
-	GasUsed          string `json:"gasUsed,omitempty"`
-	Status           string `json:"status,omitempty"`
-	Nonce            string `json:"nonce"`
-	Input            string `json:"input"`
+	Hash                 string          `json:"hash"`
+	Type                 string          `json:"type,omitempty"`
+	ChainID              string          `json:"chainId,omitempty"`
+	BlockNumber          string          `json:"blockNumber"`
+	BlockHash            string          `json:"blockHash"`
+	TransactionIndex     string          `json:"transactionIndex"`
+	From                 string          `json:"from"`
+	To                   string          `json:"to"`
+	Value                string          `json:"value"`
+	Gas                  string          `json:"gas"`
+	GasPrice             string          `json:"gasPrice"`
+	MaxFeePerGas         string          `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas string          `json:"maxPriorityFeePerGas,omitempty"`
+	EffectiveGasPrice    string          `json:"effectiveGasPrice,omitempty"`
+	MaxFeePerBlobGas     string          `json:"maxFeePerBlobGas,omitempty"`
+	BlobVersionedHashes  []string        `json:"blobVersionedHashes,omitempty"`
+	AccessList           []AccessTuple   `json:"accessList,omitempty"`
+	AuthorizationList    []Authorization `json:"authorizationList,omitempty"`
+	GasUsed              string          `json:"gasUsed,omitempty"`
+	Status               string          `json:"status,omitempty"`
+	Nonce                string          `json:"nonce"`
+	Input                string          `json:"input"`
+	V                    string          `json:"v,omitempty"`
+	R                    string          `json:"r,omitempty"`
+	S                    string          `json:"s,omitempty"`
+	DecodedInput         *DecodedInput   `json:"decodedInput,omitempty"`
+}
+
+// DecodedInput is calldata decoded against a contract ABI. Source tells
+// where the ABI came from: "registry", "explorer" or "signatures". When the
+// input cannot be decoded only Selector and Error are set.
+type DecodedInput struct {
+	Selector  string            `json:"selector"`
+	Method    string            `json:"method,omitempty"`
+	Signature string            `json:"signature,omitempty"`
+	Source    string            `json:"source,omitempty"`
+	Arguments []DecodedArgument `json:"arguments,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// DecodedArgument is a decoded ABI value. Value holds a string for integers,
+// addresses and byte strings, a bool, a list for arrays, or a list of
+// DecodedArgument for tuples.
+type DecodedArgument struct {
+	Name    string      `json:"name"`
+	Type    string      `json:"type"`
+	Indexed bool        `json:"indexed,omitempty"`
+	Value   interface{} `json:"value"`
+}
+
+// Receipt is the outcome of a mined transaction. Gas prices are in gwei and
+// Fee, the total paid including blob gas, is in ETH.
+type Receipt struct {
+	TransactionHash   string     `json:"transactionHash"`
+	Type              string     `json:"type"`
+	BlockNumber       string     `json:"blockNumber"`
+	BlockHash         string     `json:"blockHash"`
+	TransactionIndex  string     `json:"transactionIndex"`
+	Status            string     `json:"status"`
+	CumulativeGasUsed string     `json:"cumulativeGasUsed"`
+	GasUsed           string     `json:"gasUsed"`
+	EffectiveGasPrice string     `json:"effectiveGasPrice,omitempty"`
+	BlobGasUsed       string     `json:"blobGasUsed,omitempty"`
+	BlobGasPrice      string     `json:"blobGasPrice,omitempty"`
+	Fee               string     `json:"fee"`
+	ContractAddress   string     `json:"contractAddress,omitempty"`
+	LogsBloom         string     `json:"logsBloom"`
+	Logs              []EventLog `json:"logs"`
+}
+
+// AccessTuple is an EIP-2930 access list entry.
+type AccessTuple struct {
+	Address     string   `json:"address"`
+	StorageKeys []string `json:"storageKeys"`
+}
+
+// Authorization is an EIP-7702 set-code authorization. Authority is the
+// recovered signer, empty when the signature is invalid.
+type Authorization struct {
+	ChainID   string `json:"chainId"`
+	Address   string `json:"address"`
+	Nonce     string `json:"nonce"`
+	YParity   string `json:"yParity"`
+	R         string `json:"r"`
+	S         string `json:"s"`
+	Authority string `json:"authority,omitempty"`
 }
 
 type Balance struct {
@@ -46,7 +154,9 @@ type GasPrice struct {
 
 type ErrorResponse struct {
 	Error   string `json:"error"`
+	Code    string `json:"code"`
 	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
 }
 
 type TransactionHistory struct {
@@ -54,19 +164,108 @@ type TransactionHistory struct {
 	Transactions []Transaction `json:"transactions"`
 }
 
+// TokenBalance is an ERC-20 balance. Balance is the raw integer amount;
+// FormattedBalance is the same amount in whole tokens and is omitted when
+// the token does not report its decimals.
 type TokenBalance struct {
-	Address      string `json:"address"`
-	TokenAddress string `json:"token_address"`
-	Balance      string `json:"balance"`
+	Address          string `json:"address"`
+	TokenAddress     string `json:"token_address"`
+	Balance          string `json:"balance"`
+	Name             string `json:"name,omitempty"`
+	Symbol           string `json:"symbol,omitempty"`
+	Decimals         *uint8 `json:"decimals,omitempty"`
+	FormattedBalance string `json:"formatted_balance,omitempty"`
 }
 
+// Portfolio is the ETH balance of an address and its non-zero token
+// balances. FailedTokens lists tokens whose balanceOf call failed.
+type Portfolio struct {
+	Address      string         `json:"address"`
+	Balance      string         `json:"balance"`
+	BalanceWei   string         `json:"balance_wei"`
+	Tokens       []TokenBalance `json:"tokens"`
+	FailedTokens []string       `json:"failed_tokens,omitempty"`
+}
+
+// NFT is a token of an ERC-721 or ERC-1155 contract. Interfaces lists the
+// ERC-165 interfaces the contract supports. Owner is only reported for
+// ERC-721 tokens, which have a single owner.
+type NFT struct {
+	Contract   string   `json:"contract"`
+	TokenID    string   `json:"token_id"`
+	Standard   string   `json:"standard"`
+	Interfaces []string `json:"interfaces"`
+	Name       string   `json:"name,omitempty"`
+	Symbol     string   `json:"symbol,omitempty"`
+	Owner      string   `json:"owner,omitempty"`
+	TokenURI   string   `json:"token_uri,omitempty"`
+}
+
+// NFTTransfer is an ERC-721 or ERC-1155 transfer of Amount units of one
+// token. Operator is only reported for ERC-1155 transfers.
+type NFTTransfer struct {
+	Standard     string    `json:"standard"`
+	TokenAddress string    `json:"token_address"`
+	TokenID      string    `json:"token_id"`
+	Amount       string    `json:"amount"`
+	Operator     string    `json:"operator,omitempty"`
+	From         string    `json:"from"`
+	To           string    `json:"to"`
+	Direction    string    `json:"direction"`
+	BlockNumber  uint64    `json:"block_number"`
+	BlockHash    string    `json:"block_hash"`
+	Timestamp    time.Time `json:"timestamp"`
+	TxHash       string    `json:"tx_hash"`
+	LogIndex     uint      `json:"log_index"`
+}
+
+// NFTTransferPage is one page of NFT transfers. NextCursor is empty on the
+// last page. FromBlock and ToBlock are the block range being scanned.
+type NFTTransferPage struct {
+	Transfers  []NFTTransfer `json:"transfers"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+	FromBlock  uint64        `json:"from_block"`
+	ToBlock    uint64        `json:"to_block"`
+}
+
+// TokenMetadata describes an ERC-20 token. Fields whose optional function
+// the token does not implement are omitted.
+type TokenMetadata struct {
+	Address              string `json:"address"`
+	Name                 string `json:"name,omitempty"`
+	Symbol               string `json:"symbol,omitempty"`
+	Decimals             *uint8 `json:"decimals,omitempty"`
+	TotalSupply          string `json:"total_supply,omitempty"`
+	FormattedTotalSupply string `json:"formatted_total_supply,omitempty"`
+}
+
+// TokenTransfer is an ERC-20 Transfer event. Direction is "in", "out" or
+// "self" relative to the queried address; Timestamp is the block time.
+// FormattedValue is Value in whole tokens, omitted when the token does not
+// report its decimals.
 type TokenTransfer struct {
-	TokenAddress string `json:"token_address"`
-	From         string `json:"from"`
-	To           string `json:"to"`
-	Value        string `json:"value"`
-	BlockHash    string `json:"block_hash"`
-	TxHash       string `json:"tx_hash"`
+	TokenAddress   string    `json:"token_address"`
+	TokenSymbol    string    `json:"token_symbol,omitempty"`
+	TokenDecimals  *uint8    `json:"token_decimals,omitempty"`
+	From           string    `json:"from"`
+	To             string    `json:"to"`
+	Value          string    `json:"value"`
+	FormattedValue string    `json:"formatted_value,omitempty"`
+	Direction      string    `json:"direction"`
+	BlockNumber    uint64    `json:"block_number"`
+	BlockHash      string    `json:"block_hash"`
+	Timestamp      time.Time `json:"timestamp"`
+	TxHash         string    `json:"tx_hash"`
+	LogIndex       uint      `json:"log_index"`
+}
+
+// TokenTransferPage is one page of token transfers. NextCursor is empty on
+// the last page. FromBlock and ToBlock are the block range being scanned.
+type TokenTransferPage struct {
+	Transfers  []TokenTransfer `json:"transfers"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+	FromBlock  uint64          `json:"from_block"`
+	ToBlock    uint64          `json:"to_block"`
 }
 
 type ContractABI struct {
@@ -80,13 +279,252 @@ type ContractSource struct {
 }
 
 type EventLog struct {
-	Address     string   `json:"address"`
-	Topics      []string `json:"topics"`
-	Data        string   `json:"data"`
-	BlockNumber uint64   `json:"block_number"`
-	TxHash      string   `json:"tx_hash"`
-	TxIndex     uint     `json:"tx_index"`
-	BlockHash   string   `json:"block_hash"`
-	Index       uint     `json:"index"`
-	Removed     bool     `json:"removed"`
+	Address     string        `json:"address"`
+	Topics      []string      `json:"topics"`
+	Data        string        `json:"data"`
+	BlockNumber uint64        `json:"block_number"`
+	TxHash      string        `json:"tx_hash"`
+	TxIndex     uint          `json:"tx_index"`
+	BlockHash   string        `json:"block_hash"`
+	Index       uint          `json:"index"`
+	Removed     bool          `json:"removed"`
+	Decoded     *DecodedEvent `json:"decoded,omitempty"`
+}
+
+// EventLogPage is one page of event logs. NextCursor is empty on the last
+// page. FromBlock and ToBlock are the block range being scanned, omitted
+// for block hash queries.
+type EventLogPage struct {
+	Logs       []EventLog `json:"logs"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+	FromBlock  *uint64    `json:"from_block,omitempty"`
+	ToBlock    *uint64    `json:"to_block,omitempty"`
+}
+
+// Decoded event statuses.
+const (
+	EventDecoded     = "decoded"
+	EventAnonymous   = "anonymous"
+	EventUndecodable = "undecodable"
+)
+
+// DecodedEvent is a log decoded against an event ABI. Status is
+// EventDecoded, EventAnonymous for logs without a topic0 (parameters are
+// only present when an anonymous event in the contract ABI matched), or
+// EventUndecodable with the reason in Error.
+type DecodedEvent struct {
+	Status     string            `json:"status"`
+	Event      string            `json:"event,omitempty"`
+	Signature  string            `json:"signature,omitempty"`
+	Source     string            `json:"source,omitempty"`
+	Parameters []DecodedArgument `json:"parameters,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// CallRequest is the body of a read-only contract call. The function is
+// given either as a human-readable signature in Function, or as a JSON ABI
+// fragment in ABI, with Function naming the function when the fragment has
+// more than one. Block defaults to "latest".
+type CallRequest struct {
+	To             string                     `json:"to"`
+	From           string                     `json:"from,omitempty"`
+	Value          string                     `json:"value,omitempty"`
+	Function       string                     `json:"function,omitempty"`
+	ABI            json.RawMessage            `json:"abi,omitempty"`
+	Args           []json.RawMessage          `json:"args,omitempty"`
+	Block          string                     `json:"block,omitempty"`
+	StateOverrides map[string]AccountOverride `json:"state_overrides,omitempty"`
+}
+
+// AccountOverride replaces parts of an account's state for the duration of
+// a call. State replaces the whole storage, StateDiff individual slots.
+type AccountOverride struct {
+	Balance   string            `json:"balance,omitempty"`
+	Nonce     string            `json:"nonce,omitempty"`
+	Code      string            `json:"code,omitempty"`
+	State     map[string]string `json:"state,omitempty"`
+	StateDiff map[string]string `json:"state_diff,omitempty"`
+}
+
+// CallResult is the outcome of a contract call. Outputs holds the decoded
+// return values of a successful call, or Error the reason they could not
+// be decoded; Revert describes a call that reverted.
+type CallResult struct {
+	Success    bool              `json:"success"`
+	Function   string            `json:"function"`
+	ReturnData string            `json:"return_data"`
+	Outputs    []DecodedArgument `json:"outputs,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	Revert     *Revert           `json:"revert,omitempty"`
+}
+
+// Revert kinds.
+const (
+	RevertError   = "error"
+	RevertPanic   = "panic"
+	RevertCustom  = "custom"
+	RevertUnknown = "unknown"
+)
+
+// Revert is the decoded data of a reverted call. Kind is RevertError for
+// require and revert with a reason string, RevertPanic for failed asserts
+// and arithmetic errors, RevertCustom for custom errors found in the
+// contract's ABI, and RevertUnknown when the data could not be decoded.
+type Revert struct {
+	Kind      string            `json:"kind"`
+	Reason    string            `json:"reason,omitempty"`
+	PanicCode string            `json:"panic_code,omitempty"`
+	Name      string            `json:"name,omitempty"`
+	Signature string            `json:"signature,omitempty"`
+	Arguments []DecodedArgument `json:"arguments,omitempty"`
+	Data      string            `json:"data"`
+}
+
+// SimulateRequest is the body of a transaction simulation. To is omitted
+// to simulate a contract deployment, Gas to let the node pick the gas limit.
+// Block defaults to "latest".
+type SimulateRequest struct {
+	From           string                     `json:"from,omitempty"`
+	To             string                     `json:"to,omitempty"`
+	Value          string                     `json:"value,omitempty"`
+	Data           string                     `json:"data,omitempty"`
+	Gas            string                     `json:"gas,omitempty"`
+	Block          string                     `json:"block,omitempty"`
+	StateOverrides map[string]AccountOverride `json:"state_overrides,omitempty"`
+}
+
+// Simulation is the outcome of a simulated transaction. GasEstimate is only
+// set when it succeeds. Revert describes a transaction that reverted and
+// Error one the node refused to run, e.g. for insufficient funds. Logs is
+// null when the node cannot report the logs a call emits; GasUsed is then
+// omitted too.
+type Simulation struct {
+	Success     bool       `json:"success"`
+	GasEstimate uint64     `json:"gas_estimate,omitempty"`
+	GasUsed     uint64     `json:"gas_used,omitempty"`
+	ReturnData  string     `json:"return_data"`
+	Logs        []EventLog `json:"logs"`
+	Error       string     `json:"error,omitempty"`
+	Revert      *Revert    `json:"revert,omitempty"`
+}
+
+// GasFees is an EIP-1559 fee estimate derived from the fee history of the
+// Blocks blocks up to BlockNumber. Fees are in gwei, with the amount in wei
+// alongside. The blob base fee is omitted on chains without blobs.
+type GasFees struct {
+	BlockNumber    uint64            `json:"block_number"`
+	Blocks         int               `json:"blocks"`
+	BaseFee        string            `json:"base_fee"`
+	BaseFeeWei     string            `json:"base_fee_wei"`
+	NextBaseFee    string            `json:"next_base_fee"`
+	NextBaseFeeWei string            `json:"next_base_fee_wei"`
+	BlobBaseFee    string            `json:"blob_base_fee,omitempty"`
+	BlobBaseFeeWei string            `json:"blob_base_fee_wei,omitempty"`
+	Slow           FeeRecommendation `json:"slow"`
+	Standard       FeeRecommendation `json:"standard"`
+	Fast           FeeRecommendation `json:"fast"`
+}
+
+// FeeRecommendation is the maxPriorityFeePerGas and maxFeePerGas to use for
+// a transaction to be included at one speed. Percentile is the percentile
+// of the priority fees paid in recent blocks the tip is based on.
+type FeeRecommendation struct {
+	Percentile              float64 `json:"percentile"`
+	MaxPriorityFeePerGas    string  `json:"max_priority_fee_per_gas"`
+	MaxPriorityFeePerGasWei string  `json:"max_priority_fee_per_gas_wei"`
+	MaxFeePerGas            string  `json:"max_fee_per_gas"`
+	MaxFeePerGasWei         string  `json:"max_fee_per_gas_wei"`
+}
+
+// GasHistory is a time series of base fees, block utilization and priority
+// fees over recent blocks, oldest first. Each point is a block or, with a
+// Bucket, the blocks of one minute or hour.
+type GasHistory struct {
+	Bucket      string            `json:"bucket,omitempty"`
+	Percentiles []float64         `json:"percentiles"`
+	Points      []GasHistoryPoint `json:"points"`
+}
+
+// GasHistoryPoint covers the Blocks blocks from FromBlock to ToBlock. For a
+// single block, Timestamp is the block time; for a bucket, it is the start
+// of the bucket and the base fee and gas used ratio are averages. Priority
+// fees hold one fee per percentile of GasHistory.Percentiles, in gwei with
+// the amounts in wei alongside.
+type GasHistoryPoint struct {
+	Timestamp       time.Time `json:"timestamp"`
+	FromBlock       uint64    `json:"from_block"`
+	ToBlock         uint64    `json:"to_block"`
+	Blocks          int       `json:"blocks"`
+	BaseFee         string    `json:"base_fee"`
+	BaseFeeWei      string    `json:"base_fee_wei"`
+	GasUsedRatio    float64   `json:"gas_used_ratio"`
+	PriorityFees    []string  `json:"priority_fees"`
+	PriorityFeesWei []string  `json:"priority_fees_wei"`
+}
+
+// SendTransactionRequest is the body of a transaction broadcast.
+// RawTransaction is the 0x-prefixed hex of a signed transaction.
+type SendTransactionRequest struct {
+	RawTransaction string `json:"raw_transaction"`
+}
+
+// Transaction statuses.
+const (
+	TxPending   = "pending"
+	TxIncluded  = "included"
+	TxFinalized = "finalized"
+	TxReplaced  = "replaced"
+	TxDropped   = "dropped"
+)
+
+// TransactionStatus is where a transaction is in its lifecycle. The block
+// fields are set once it is included, with Success telling whether it
+// executed successfully.
+type TransactionStatus struct {
+	Hash          string `json:"hash"`
+	Status        string `json:"status"`
+	From          string `json:"from,omitempty"`
+	Nonce         uint64 `json:"nonce"`
+	BlockNumber   uint64 `json:"block_number,omitempty"`
+	BlockHash     string `json:"block_hash,omitempty"`
+	Confirmations uint64 `json:"confirmations,omitempty"`
+	Success       *bool  `json:"success,omitempty"`
+}
+
+// TransactionTrace is the call tree of a transaction and the ETH transfers
+// made by the contracts it called, which the transaction itself does not
+// show.
+type TransactionTrace struct {
+	Hash              string             `json:"hash"`
+	Call              CallFrame          `json:"call"`
+	InternalTransfers []InternalTransfer `json:"internal_transfers"`
+}
+
+// CallFrame is a call in a transaction's call tree: the transaction's own
+// call at the root, and the calls each call made, in order, in Calls. Type
+// is the opcode, e.g. CALL, DELEGATECALL or CREATE2. Value is in wei.
+// Revert describes a call that reverted.
+type CallFrame struct {
+	Type    string      `json:"type"`
+	From    string      `json:"from"`
+	To      string      `json:"to,omitempty"`
+	Value   string      `json:"value,omitempty"`
+	Gas     uint64      `json:"gas"`
+	GasUsed uint64      `json:"gas_used"`
+	Input   string      `json:"input"`
+	Output  string      `json:"output,omitempty"`
+	Error   string      `json:"error,omitempty"`
+	Revert  *Revert     `json:"revert,omitempty"`
+	Calls   []CallFrame `json:"calls,omitempty"`
+}
+
+// InternalTransfer is an ETH transfer made by a contract during a
+// transaction. Depth is that of the call in the call tree, 1 for calls
+// made by the transaction's recipient. Value is in wei.
+type InternalTransfer struct {
+	Type  string `json:"type"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Value string `json:"value"`
+	Depth int    `json:"depth"`
 }
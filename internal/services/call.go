@@ -0,0 +1,164 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"eth-explorer-api/internal/decoder"
+	"eth-explorer-api/internal/models"
+	"eth-explorer-api/internal/validation"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Call runs a read-only call of a contract function at a block, optionally
+// from a given sender, with a value and with state overrides. Arguments are
+// ABI-encoded from JSON. A call that reverts is not an error: the result
+// reports the decoded revert reason, custom errors being looked up in the
+// request's ABI fragment and the contract's ABI.
+func (s *EthService) Call(ctx context.Context, req *models.CallRequest) (*models.CallResult, error) {
+	to, err := s.parseAddress("to", req.To)
+	if err != nil {
+		return nil, err
+	}
+	msg := ethereum.CallMsg{To: &to}
+	if req.From != "" {
+		if msg.From, err = s.parseAddress("from", req.From); err != nil {
+			return nil, err
+		}
+	}
+	if req.Value != "" {
+		if msg.Value, err = validation.Uint256("value", req.Value); err != nil {
+			return nil, invalidInputError("invalid request", err)
+		}
+	}
+	block, err := s.parseStateBlock("block", req.Block)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := s.parseStateOverrides("state_overrides", req.StateOverrides)
+	if err != nil {
+		return nil, err
+	}
+
+	method, fragment, err := resolveFunction(req.Function, req.ABI)
+	if err != nil {
+		return nil, err
+	}
+	args, err := decoder.EncodeArguments(method.Inputs, req.Args)
+	if err != nil {
+		return nil, invalidInputError("invalid request", &validation.FieldError{Field: "args", Reason: err.Error()})
+	}
+	msg.Data = append(append([]byte{}, method.ID...), args...)
+
+	result := &models.CallResult{Function: method.Sig}
+	output, err := s.callWithOverrides(ctx, msg, block, overrides)
+	if err != nil {
+		data, ok := revertData(err)
+		if !ok {
+			return nil, stateError("failed to call contract", err)
+		}
+		contractABI, _ := s.contractABI(ctx, to)
+		result.ReturnData = hexutil.Encode(data)
+		result.Revert = decoder.DecodeRevert(data, fragment, contractABI)
+		return result, nil
+	}
+
+	result.Success = true
+	result.ReturnData = hexutil.Encode(output)
+	if len(method.Outputs) > 0 {
+		if len(output) == 0 {
+			result.Error = "the call returned no data; is there a contract at " + to.Hex() + "?"
+		} else if result.Outputs, err = decoder.DecodeArguments(method.Outputs, output); err != nil {
+			result.Error = err.Error()
+		}
+	}
+	return result, nil
+}
+
+// resolveFunction returns the function to call, given either as a
+// human-readable signature or as a JSON ABI fragment, along with the
+// fragment so that custom errors it declares can be decoded. In a fragment
+// with several functions, function selects one by name or signature.
+func resolveFunction(function string, fragment []byte) (*abi.Method, *abi.ABI, error) {
+	if len(bytes.TrimSpace(fragment)) == 0 {
+		if function == "" {
+			return nil, nil, invalidInputError("invalid request", &validation.FieldError{Field: "function", Reason: "is required when no abi is given"})
+		}
+		method, err := decoder.ParseFunction(function)
+		if err != nil {
+			return nil, nil, invalidInputError("invalid request", &validation.FieldError{Field: "function", Value: function, Reason: err.Error()})
+		}
+		return method, nil, nil
+	}
+
+	contractABI, err := parseABIFragment(fragment)
+	if err != nil {
+		return nil, nil, invalidInputError("invalid request", &validation.FieldError{Field: "abi", Reason: err.Error()})
+	}
+
+	var candidates []abi.Method
+	for _, method := range contractABI.Methods {
+		if function == "" || method.RawName == function || method.Sig == function {
+			candidates = append(candidates, method)
+		}
+	}
+	if len(candidates) == 0 && function != "" {
+		// The function may be a full signature with parameter names.
+		if parsed, err := decoder.ParseFunction(function); err == nil {
+			if method, err := contractABI.MethodById(parsed.ID); err == nil {
+				candidates = append(candidates, *method)
+			}
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		if function == "" {
+			return nil, nil, invalidInputError("invalid request", &validation.FieldError{Field: "abi", Reason: "declares no functions"})
+		}
+		return nil, nil, invalidInputError("invalid request", &validation.FieldError{Field: "function", Value: function, Reason: "is not in the abi"})
+	case 1:
+		return &candidates[0], contractABI, nil
+	}
+	signatures := make([]string, len(candidates))
+	for i, method := range candidates {
+		signatures[i] = method.Sig
+	}
+	return nil, nil, invalidInputError("invalid request", &validation.FieldError{Field: "function", Value: function, Reason: "is ambiguous; use one of " + strings.Join(signatures, ", ")})
+}
+
+// parseABIFragment parses a JSON ABI, a build artifact with an "abi" field
+// or a single ABI entry.
+func parseABIFragment(fragment []byte) (*abi.ABI, error) {
+	contractABI, err := decoder.ParseABI(fragment)
+	if err != nil && bytes.HasPrefix(bytes.TrimSpace(fragment), []byte("{")) {
+		// Not an artifact, so a single entry.
+		return decoder.ParseABI(fmt.Appendf(nil, "[%s]", fragment))
+	}
+	return contractABI, err
+}
+
+// revertData returns the data of a call that reverted. Reverts without data
+// yield an empty slice.
+func revertData(err error) ([]byte, bool) {
+	if !isExecutionReverted(err) {
+		return nil, false
+	}
+
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if data, ok := dataErr.ErrorData().(string); ok {
+			if decoded, err := hexutil.Decode(data); err == nil {
+				return decoded, true
+			}
+		}
+	}
+	return []byte{}, true
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"eth-explorer-api/internal/explorer"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Error kinds. Every error returned by EthService matches exactly one of
+// these with errors.Is, except failures of the service itself, such as
+// failing to encode a call, which match none.
+var (
+	ErrInvalidInput        = errors.New("invalid input")
+	ErrNotFound            = errors.New("not found")
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")
+	ErrRateLimited         = errors.New("rate limited")
+	ErrTimeout             = errors.New("timeout")
+	ErrStateUnavailable    = errors.New("historical state unavailable")
+	ErrUnsupported         = errors.New("unsupported by the node")
+)
+
+// Error is a classified EthService failure. It unwraps to both its Kind and
+// the underlying cause.
+type Error struct {
+	Kind error
+	Msg  string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Msg
+	}
+	return e.Msg + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// JSON-RPC error codes that carry a meaning beyond "the node failed".
+const (
+	rpcMethodNotFoundCode = -32601
+	rpcInvalidParamsCode  = -32602
+	rpcLimitExceededCode  = -32005
+
+	// rpcVMErrorCode is Geth's code for calls that failed in the EVM other
+	// than by reverting, e.g. by running out of gas.
+	rpcVMErrorCode = -32015
+)
+
+func invalidInputError(msg string, err error) error {
+	return &Error{Kind: ErrInvalidInput, Msg: msg, Err: err}
+}
+
+func notFoundError(msg string, err error) error {
+	return &Error{Kind: ErrNotFound, Msg: msg, Err: err}
+}
+
+func unsupportedError(msg string, err error) error {
+	return &Error{Kind: ErrUnsupported, Msg: msg, Err: err}
+}
+
+// stateError classifies a failure to read account state or run a call at a
+// given block. Besides the failures upstreamError recognises, the node may
+// have pruned the state of the block or not know the block at all. Errors
+// that are already classified are returned unchanged.
+func stateError(msg string, err error) error {
+	var classified *Error
+	switch {
+	case errors.As(err, &classified):
+		return err
+	case isStateUnavailable(err):
+		return &Error{Kind: ErrStateUnavailable, Msg: msg + ": the node does not have the state of this block; an archive node is required", Err: err}
+	case isUnknownBlock(err):
+		return notFoundError(msg, err)
+	}
+	return upstreamError(msg, err)
+}
+
+// upstreamError classifies a failure reported by the Ethereum node or the
+// explorer API.
+func upstreamError(msg string, err error) error {
+	return &Error{Kind: classify(err), Msg: msg, Err: err}
+}
+
+func classify(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrTimeout
+	}
+
+	if errors.Is(err, ethereum.NotFound) {
+		return ErrNotFound
+	}
+
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusTooManyRequests {
+		return ErrRateLimited
+	}
+
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		switch rpcErr.ErrorCode() {
+		case rpcLimitExceededCode:
+			return ErrRateLimited
+		case rpcInvalidParamsCode:
+			return ErrInvalidInput
+		}
+	}
+
+	var explorerHTTPErr *explorer.HTTPError
+	if errors.As(err, &explorerHTTPErr) && explorerHTTPErr.StatusCode == http.StatusTooManyRequests {
+		return ErrRateLimited
+	}
+
+	var apiErr *explorer.APIError
+	if errors.As(err, &apiErr) {
+		detail := strings.ToLower(apiErr.Result)
+		switch {
+		case strings.Contains(detail, "rate limit"):
+			return ErrRateLimited
+		case strings.Contains(detail, "not verified"):
+			return ErrNotFound
+		case strings.HasPrefix(detail, "invalid address"):
+			return ErrInvalidInput
+		}
+	}
+
+	return ErrUpstreamUnavailable
+}
+
+// isMethodNotFound reports whether the node rejected a call because it does
+// not implement the JSON-RPC method.
+func isMethodNotFound(err error) bool {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == rpcMethodNotFoundCode {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "method not found") ||
+		(strings.Contains(msg, "method") && strings.Contains(msg, "does not exist"))
+}
+
+// tooManyResultsMessages are fragments of the errors providers return when
+// an eth_getLogs range matches too many logs or spans too many blocks.
+var tooManyResultsMessages = []string{
+	"query returned more than",
+	"too many results",
+	"response size exceeded",
+	"response size is larger",
+	"block range",
+	"range is too large",
+	"range too large",
+	"range is too wide",
+}
+
+// isTooManyResults reports whether the node rejected an eth_getLogs call
+// because its block range should be narrowed.
+func isTooManyResults(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, fragment := range tooManyResultsMessages {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// transactionFailureMessages are fragments of the errors nodes return when
+// a call or gas estimate cannot succeed because of the transaction itself.
+var transactionFailureMessages = []string{
+	"insufficient funds",
+	"intrinsic gas too low",
+	"gas required exceeds",
+	"out of gas",
+	"invalid opcode",
+	"stack underflow",
+	"stack overflow",
+	"nonce too",
+	"max initcode size exceeded",
+	"contract creation code storage out of gas",
+	"exceeds block gas limit",
+	"sender not an eoa",
+}
+
+// transactionFailure returns the reason the node gave for refusing to run
+// a transaction, such as insufficient funds or too little gas, or "" when
+// err is a failure of the node itself.
+func transactionFailure(err error) string {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) || isStateUnavailable(err) || isUnknownBlock(err) {
+		return ""
+	}
+	// Geth's eth_simulateV1 reports invalid transactions with -380xx codes.
+	if code := rpcErr.ErrorCode(); code == rpcVMErrorCode || (code <= -38000 && code > -39000) {
+		return rpcErr.Error()
+	}
+	msg := strings.ToLower(rpcErr.Error())
+	for _, fragment := range transactionFailureMessages {
+		if strings.Contains(msg, fragment) {
+			return rpcErr.Error()
+		}
+	}
+	return ""
+}
+
+// isExecutionReverted reports whether a call failed because the contract
+// reverted, as opposed to the node failing to run it.
+func isExecutionReverted(err error) bool {
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) && dataErr.ErrorData() != nil {
+		return true
+	}
+	return strings.Contains(strings.ToLower(err.Error()), "execution reverted")
+}
+
+// stateUnavailableMessages are fragments of the errors nodes return when the
+// state of a block has been pruned, from Geth, Erigon, Nethermind, Besu and
+// hosted providers.
+var stateUnavailableMessages = []string{
+	"missing trie node",
+	"historical state",
+	"state is not available",
+	"state not available",
+	"no state available",
+	"state unavailable",
+	"state is pruned",
+}
+
+// isStateUnavailable reports whether the node failed because it is not an
+// archive node and no longer has the state of the requested block.
+func isStateUnavailable(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, fragment := range stateUnavailableMessages {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// isUnknownBlock reports whether the node failed because the requested block
+// does not exist.
+func isUnknownBlock(err error) bool {
+	if errors.Is(err, ethereum.NotFound) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "header not found") ||
+		strings.Contains(msg, "header for hash not found") ||
+		strings.Contains(msg, "unknown block")
+}
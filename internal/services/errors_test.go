@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"eth-explorer-api/internal/explorer"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type rpcError struct{ code int }
+
+func (e rpcError) Error() string  { return fmt.Sprintf("rpc error %d", e.code) }
+func (e rpcError) ErrorCode() int { return e.code }
+
+func TestUpstreamErrorKind(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"deadline", fmt.Errorf("post: %w", context.DeadlineExceeded), ErrTimeout},
+		{"not found", ethereum.NotFound, ErrNotFound},
+		{"http 429", rpc.HTTPError{StatusCode: http.StatusTooManyRequests}, ErrRateLimited},
+		{"http 503", rpc.HTTPError{StatusCode: http.StatusServiceUnavailable}, ErrUpstreamUnavailable},
+		{"rpc limit exceeded", rpcError{rpcLimitExceededCode}, ErrRateLimited},
+		{"rpc invalid params", rpcError{rpcInvalidParamsCode}, ErrInvalidInput},
+		{"explorer http 429", &explorer.HTTPError{StatusCode: http.StatusTooManyRequests}, ErrRateLimited},
+		{"explorer http 502", &explorer.HTTPError{StatusCode: http.StatusBadGateway}, ErrUpstreamUnavailable},
+		{"explorer rate limit", &explorer.APIError{Message: "NOTOK", Result: "Max rate limit reached"}, ErrRateLimited},
+		{"explorer unverified", &explorer.APIError{Message: "NOTOK", Result: "Contract source code not verified"}, ErrNotFound},
+		{"other", errors.New("connection refused"), ErrUpstreamUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := upstreamError("failed", tt.err)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("kind of %v = %v, want %v", err, err.(*Error).Kind, tt.want)
+			}
+			if !strings.HasSuffix(err.Error(), tt.err.Error()) {
+				t.Errorf("%v does not report its cause", err)
+			}
+		})
+	}
+}
+
+func TestStateErrorKind(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"geth pruned", errors.New("missing trie node 9ad4c0e2 (path )"), ErrStateUnavailable},
+		{"historical state", errors.New("historical state not available in path scheme yet"), ErrStateUnavailable},
+		{"unknown block", errors.New("header not found"), ErrNotFound},
+		{"provider plan", rpc.HTTPError{StatusCode: http.StatusTooManyRequests, Body: []byte("archive requests require a paid plan")}, ErrRateLimited},
+		{"provider auth", errors.New("archive requests require a paid plan"), ErrUpstreamUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := stateError("failed", tt.err); !errors.Is(err, tt.want) {
+				t.Errorf("kind of %v = %v, want %v", err, err.(*Error).Kind, tt.want)
+			}
+		})
+	}
+}
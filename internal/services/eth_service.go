@@ -2,95 +2,269 @@ package services
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"math/big"
-	"net/http"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"eth-explorer-api/internal/decoder"
+	"eth-explorer-api/internal/explorer"
 	"eth-explorer-api/internal/models"
+	"eth-explorer-api/internal/validation"
 
 	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
+// ChainReader is the subset of the Ethereum JSON-RPC API used by EthService.
+// It is satisfied by *ethclient.Client and by the client of go-ethereum's
+// simulated backend.
+type ChainReader interface {
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+	BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	BlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error)
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	BalanceAtHash(ctx context.Context, account common.Address, blockHash common.Hash) (*big.Int, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error)
+	BlobBaseFee(ctx context.Context) (*big.Int, error)
+	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+	CallContractAtHash(ctx context.Context, msg ethereum.CallMsg, blockHash common.Hash) ([]byte, error)
+	EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+	NetworkID(ctx context.Context) (*big.Int, error)
+	ChainID(ctx context.Context) (*big.Int, error)
+}
+
 type EthService struct {
-	client          *ethclient.Client
-	etherscanAPIKey string
+	client         ChainReader
+	explorer       *explorer.Client
+	strictChecksum bool
+
+	// abis holds locally configured contract ABIs; explorerABIs caches
+	// ABIs fetched from the explorer API and unverified the contracts it
+	// reported as not verified.
+	abis         *decoder.Registry
+	explorerABIs *decoder.Registry
+	unverified   *unverifiedContracts
+
+	tokens       *tokenCache
+	tokenList    []TokenListEntry
+	multicall    common.Address
+	logChunkSize uint64
+
+	feeHistoryBlocks uint64
+
+	// gasHistory is nil until StartGasHistory is called.
+	gasHistory *gasHistory
+
+	sent *sentTransactions
 }
 
-func NewEthService(nodeURL, etherscanAPIKey string) (*EthService, error) {
+func NewEthService(nodeURL string, explorerClient *explorer.Client) (*EthService, error) {
 	client, err := ethclient.Dial(nodeURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Ethereum node: %w", err)
 	}
 
+	return NewEthServiceWithClient(client, explorerClient), nil
+}
+
+// NewEthServiceWithClient creates an EthService backed by an existing client.
+func NewEthServiceWithClient(client ChainReader, explorerClient *explorer.Client) *EthService {
 	return &EthService{
-		client:          client,
-		etherscanAPIKey: etherscanAPIKey,
-	}, nil
+		client:       client,
+		explorer:     explorerClient,
+		abis:         decoder.NewRegistry(),
+		explorerABIs: decoder.NewRegistry(),
+		unverified:   newUnverifiedContracts(),
+		tokens:       newTokenCache(),
+		multicall:    DefaultMulticall3Address,
+		logChunkSize: defaultLogChunkSize,
+
+		feeHistoryBlocks: defaultFeeHistoryBlocks,
+		sent:             newSentTransactions(),
+	}
+}
+
+// SetABIRegistry sets the local contract ABIs consulted before the explorer
+// API when decoding calldata and logs.
+func (s *EthService) SetABIRegistry(registry *decoder.Registry) {
+	s.abis = registry
+}
+
+// SetStrictChecksum makes the service reject addresses that are not in
+// EIP-55 checksummed form.
+func (s *EthService) SetStrictChecksum(strict bool) {
+	s.strictChecksum = strict
 }
 
-func (s *EthService) GetBlock(blockNumber string) (*models.Block, error) {
-	ctx := context.Background()
+// blockTags maps the named blocks accepted in place of a block number to
+// their JSON-RPC block numbers.
+var blockTags = map[string]rpc.BlockNumber{
+	"latest":    rpc.LatestBlockNumber,
+	"safe":      rpc.SafeBlockNumber,
+	"finalized": rpc.FinalizedBlockNumber,
+	"pending":   rpc.PendingBlockNumber,
+	"earliest":  rpc.EarliestBlockNumber,
+}
+
+// GetBlock retrieves a block by number or by one of the tags in blockTags.
+// Tag lookups report the tag in the returned block.
+func (s *EthService) GetBlock(ctx context.Context, blockNumber string) (*models.Block, error) {
+	blockNum, tag, err := s.parseBlockID("number", blockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := s.client.BlockByNumber(ctx, blockNum)
+	if err != nil {
+		return nil, upstreamError("failed to fetch block", err)
+	}
+
+	model := s.blockToModel(block)
+	model.Tag = tag
+	return model, nil
+}
+
+// GetBlockWithTransactions retrieves a block like GetBlock, with every
+// transaction fully populated from the block body and its receipts.
+func (s *EthService) GetBlockWithTransactions(ctx context.Context, blockNumber string) (*models.BlockWithTransactions, error) {
+	blockNum, tag, err := s.parseBlockID("number", blockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := s.client.BlockByNumber(ctx, blockNum)
+	if err != nil {
+		return nil, upstreamError("failed to fetch block", err)
+	}
+
+	// Pending blocks have no receipts yet.
+	var receipts []*types.Receipt
+	if tag != "pending" {
+		receipts, err = s.blockReceipts(ctx, block)
+		if err != nil {
+			return nil, err
+		}
+		if len(receipts) != len(block.Transactions()) {
+			return nil, upstreamError("failed to fetch block receipts", fmt.Errorf("got %d receipts for %d transactions", len(receipts), len(block.Transactions())))
+		}
+	}
 
-	var blockNum *big.Int
-	var err error
+	chainID, err := s.client.ChainID(ctx)
+	if err != nil {
+		return nil, upstreamError("failed to get chain ID", err)
+	}
+	signer := types.LatestSignerForChainID(chainID)
 
-	if blockNumber == "latest" {
-		blockNum = nil
-	} else {
-		blockNum, err = s.parseBlockNumber(blockNumber)
+	blockNumberStr := block.Number().String()
+	blockHash := block.Hash().Hex()
+	transactions := make([]models.Transaction, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		from, err := types.Sender(signer, tx)
 		if err != nil {
-			return nil, fmt.Errorf("invalid block number: %w", err)
+			return nil, upstreamError("failed to get sender of "+tx.Hash().Hex(), err)
+		}
+
+		var receipt *types.Receipt
+		if receipts != nil {
+			receipt = receipts[i]
+			if receipt.TxHash != tx.Hash() {
+				return nil, missingReceiptError(tx.Hash())
+			}
 		}
+
+		transactions[i] = *s.transactionToModel(tx, blockNumberStr, blockHash, strconv.Itoa(i), receipt, from.Hex())
+	}
+
+	model := s.blockToModel(block)
+	model.Tag = tag
+	return &models.BlockWithTransactions{
+		Block:        *model,
+		Transactions: transactions,
+	}, nil
+}
+
+// GetBlockWithdrawals retrieves the beacon chain withdrawals processed in a
+// block, identified by number or tag.
+func (s *EthService) GetBlockWithdrawals(ctx context.Context, blockNumber string) (*models.BlockWithdrawals, error) {
+	blockNum, _, err := s.parseBlockID("number", blockNumber)
+	if err != nil {
+		return nil, err
 	}
 
 	block, err := s.client.BlockByNumber(ctx, blockNum)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch block: %w", err)
+		return nil, upstreamError("failed to fetch block", err)
+	}
+
+	return &models.BlockWithdrawals{
+		BlockNumber: block.Number().String(),
+		BlockHash:   block.Hash().Hex(),
+		Withdrawals: s.withdrawalsToModel(block.Withdrawals()),
+	}, nil
+}
+
+// GetBlockByHash retrieves a block by its hash.
+func (s *EthService) GetBlockByHash(ctx context.Context, blockHash string) (*models.Block, error) {
+	hash, err := s.parseHash("hash", blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := s.client.BlockByHash(ctx, hash)
+	if err != nil {
+		return nil, upstreamError("failed to fetch block", err)
 	}
 
 	return s.blockToModel(block), nil
 }
 
-func (s *EthService) GetTransaction(txHash string) (*models.Transaction, error) {
-	ctx := context.Background()
+func (s *EthService) GetTransaction(ctx context.Context, txHash string) (*models.Transaction, error) {
+	hash, err := s.parseHash("hash", txHash)
+	if err != nil {
+		return nil, err
+	}
 
-	hash := common.HexToHash(txHash)
 	tx, isPending, err := s.client.TransactionByHash(ctx, hash)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch transaction: %w", err)
+		return nil, upstreamError("failed to fetch transaction", err)
 	}
 
 	if isPending {
-		return s.transactionToModel(tx, "", "", "", "", ""), nil
+		return s.transactionToModel(tx, "", "", "", nil, ""), nil
 	}
 
 	receipt, err := s.client.TransactionReceipt(ctx, hash)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch transaction receipt: %w", err)
-	}
-
-	status := "1"
-	if receipt.Status == 0 {
-		status = "0"
+		return nil, upstreamError("failed to fetch transaction receipt", err)
 	}
 
 	chainID, err := s.client.NetworkID(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get network ID: %w", err)
+		return nil, upstreamError("failed to get network ID", err)
 	}
 	signer := types.LatestSignerForChainID(chainID)
 	from, err := types.Sender(signer, tx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get sender: %w", err)
+		return nil, upstreamError("failed to get sender", err)
 	}
 
 	return s.transactionToModel(
@@ -98,18 +272,144 @@ func (s *EthService) GetTransaction(txHash string) (*models.Transaction, error)
 		receipt.BlockNumber.String(),
 		receipt.BlockHash.Hex(),
 		strconv.FormatUint(uint64(receipt.TransactionIndex), 10),
-		status,
+		receipt,
 		from.Hex(),
 	), nil
 }
 
-func (s *EthService) GetBalance(address string) (*models.Balance, error) {
-	ctx := context.Background()
+// GetDecodedTransaction retrieves a transaction like GetTransaction and
+// decodes its input data.
+func (s *EthService) GetDecodedTransaction(ctx context.Context, txHash string) (*models.Transaction, error) {
+	tx, err := s.GetTransaction(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if tx.To != "" {
+		tx.DecodedInput = s.decodeInput(ctx, common.HexToAddress(tx.To), tx.Input)
+	}
+	return tx, nil
+}
+
+// decodeInput decodes calldata sent to a contract using, in order, the local
+// ABI registry, the explorer's verified ABI and the embedded table of
+// well-known functions. It returns nil for calls without calldata.
+func (s *EthService) decodeInput(ctx context.Context, to common.Address, input string) *models.DecodedInput {
+	data, err := hexutil.Decode(input)
+	if err != nil || len(data) < 4 {
+		return nil
+	}
+
+	if contractABI, source := s.contractABI(ctx, to); contractABI != nil {
+		if method, err := contractABI.MethodById(data[:4]); err == nil {
+			decoded, err := decoder.DecodeCall(method, data)
+			if err != nil {
+				return &models.DecodedInput{Selector: hexutil.Encode(data[:4]), Source: source, Error: err.Error()}
+			}
+			decoded.Source = source
+			return decoded
+		}
+	}
 
-	addr := common.HexToAddress(address)
-	balance, err := s.client.BalanceAt(ctx, addr, nil)
+	decoded, err := decoder.DecodeCallBySignature(data)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch balance: %w", err)
+		return &models.DecodedInput{Selector: hexutil.Encode(data[:4]), Error: err.Error()}
+	}
+	decoded.Source = "signatures"
+	return decoded
+}
+
+// contractABI resolves the ABI of the contract at address and reports where
+// it came from. It returns nil when no ABI is available.
+func (s *EthService) contractABI(ctx context.Context, address common.Address) (*abi.ABI, string) {
+	if contractABI, ok := s.abis.Get(address); ok {
+		return contractABI, "registry"
+	}
+	if contractABI, ok := s.explorerABIs.Get(address); ok {
+		return contractABI, "explorer"
+	}
+	if s.explorer == nil || s.unverified.has(address) {
+		return nil, ""
+	}
+
+	raw, err := s.explorer.ContractABI(ctx, address.Hex())
+	if err != nil {
+		if classify(err) == ErrNotFound {
+			s.unverified.add(address)
+		}
+		return nil, ""
+	}
+	contractABI, err := decoder.ParseABI([]byte(raw))
+	if err != nil {
+		return nil, ""
+	}
+	s.explorerABIs.Add(address, contractABI)
+	return contractABI, "explorer"
+}
+
+// unverifiedContractTTL is how long a contract the explorer has no ABI for
+// is not asked about again. Contracts can be verified at any time, so the
+// answer is only kept briefly.
+const unverifiedContractTTL = 10 * time.Minute
+
+// unverifiedContracts remembers contracts the explorer reported as not
+// verified, until unverifiedContractTTL has passed.
+type unverifiedContracts struct {
+	mu      sync.Mutex
+	expires map[common.Address]time.Time
+}
+
+func newUnverifiedContracts() *unverifiedContracts {
+	return &unverifiedContracts{expires: make(map[common.Address]time.Time)}
+}
+
+func (c *unverifiedContracts) add(address common.Address) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expires[address] = time.Now().Add(unverifiedContractTTL)
+}
+
+func (c *unverifiedContracts) has(address common.Address) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expires, ok := c.expires[address]
+	if ok && time.Now().After(expires) {
+		delete(c.expires, address)
+		return false
+	}
+	return ok
+}
+
+// GetTransactionReceipt retrieves the receipt of a mined transaction.
+func (s *EthService) GetTransactionReceipt(ctx context.Context, txHash string) (*models.Receipt, error) {
+	hash, err := s.parseHash("hash", txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt, err := s.client.TransactionReceipt(ctx, hash)
+	if err != nil {
+		return nil, upstreamError("failed to fetch transaction receipt", err)
+	}
+
+	return s.receiptToModel(receipt), nil
+}
+
+// GetBalance retrieves the ETH balance of an address at a block number, tag
+// or hash, or at the latest block when block is empty.
+func (s *EthService) GetBalance(ctx context.Context, address, block string) (*models.Balance, error) {
+	addr, err := s.parseAddress("address", address)
+	if err != nil {
+		return nil, err
+	}
+	at, err := s.parseStateBlock("block", block)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := s.balanceAt(ctx, addr, at)
+	if err != nil {
+		return nil, err
 	}
 
 	balanceEth := s.weiToEther(balance)
@@ -121,16 +421,14 @@ func (s *EthService) GetBalance(address string) (*models.Balance, error) {
 	}, nil
 }
 
-func (s *EthService) GetLatestBlock() (*models.Block, error) {
-	return s.GetBlock("latest")
+func (s *EthService) GetLatestBlock(ctx context.Context) (*models.Block, error) {
+	return s.GetBlock(ctx, "latest")
 }
 
-func (s *EthService) GetGasPrice() (*models.GasPrice, error) {
-	ctx := context.Background()
-
+func (s *EthService) GetGasPrice(ctx context.Context) (*models.GasPrice, error) {
 	gasPrice, err := s.client.SuggestGasPrice(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch gas price: %w", err)
+		return nil, upstreamError("failed to fetch gas price", err)
 	}
 
 	gasPriceGwei := s.weiToGwei(gasPrice)
@@ -141,20 +439,43 @@ func (s *EthService) GetGasPrice() (*models.GasPrice, error) {
 	}, nil
 }
 
-func (s *EthService) parseBlockNumber(blockNumber string) (*big.Int, error) {
-	num, err := strconv.ParseInt(blockNumber, 10, 64)
+func (s *EthService) parseBlockNumber(field, value string) (*big.Int, error) {
+	num, err := validation.BlockNumber(field, value)
 	if err != nil {
+		return nil, invalidInputError("invalid request", err)
+	}
+	return num, nil
+}
 
-		if blockNumber[:2] == "0x" {
-			num, err = strconv.ParseInt(blockNumber[2:], 16, 64)
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			return nil, err
-		}
+// parseBlockID parses a block number or tag. For tags it also returns the
+// normalized tag name.
+func (s *EthService) parseBlockID(field, value string) (*big.Int, string, error) {
+	tag := strings.ToLower(value)
+	if num, ok := blockTags[tag]; ok {
+		return big.NewInt(int64(num)), tag, nil
+	}
+
+	num, err := s.parseBlockNumber(field, value)
+	if err != nil {
+		return nil, "", err
+	}
+	return num, "", nil
+}
+
+func (s *EthService) parseAddress(field, value string) (common.Address, error) {
+	addr, err := validation.Address(field, value, s.strictChecksum)
+	if err != nil {
+		return common.Address{}, invalidInputError("invalid request", err)
 	}
-	return big.NewInt(num), nil
+	return addr, nil
+}
+
+func (s *EthService) parseHash(field, value string) (common.Hash, error) {
+	hash, err := validation.Hash(field, value)
+	if err != nil {
+		return common.Hash{}, invalidInputError("invalid request", err)
+	}
+	return hash, nil
 }
 
 func (s *EthService) blockToModel(block *types.Block) *models.Block {
@@ -163,7 +484,8 @@ func (s *EthService) blockToModel(block *types.Block) *models.Block {
 		transactions[i] = tx.Hash().Hex()
 	}
 
-	return &models.Block{
+	header := block.Header()
+	model := &models.Block{
 		Number:       block.Number().String(),
 		Hash:         block.Hash().Hex(),
 		ParentHash:   block.ParentHash().Hex(),
@@ -171,20 +493,71 @@ func (s *EthService) blockToModel(block *types.Block) *models.Block {
 		Miner:        block.Coinbase().Hex(),
 		GasLimit:     strconv.FormatUint(block.GasLimit(), 10),
 		GasUsed:      strconv.FormatUint(block.GasUsed(), 10),
-		Difficulty:   block.Difficulty().String(),
 		Size:         strconv.FormatUint(block.Size(), 10),
+		StateRoot:    header.Root.Hex(),
+		ReceiptsRoot: header.ReceiptHash.Hex(),
+		LogsBloom:    fmt.Sprintf("0x%x", header.Bloom.Bytes()),
+		ExtraData:    fmt.Sprintf("0x%x", header.Extra),
+		MixHash:      header.MixDigest.Hex(),
+		Nonce:        fmt.Sprintf("0x%x", header.Nonce[:]),
 		Transactions: transactions,
 	}
+
+	// Difficulty is always zero after the merge.
+	if header.Difficulty != nil && header.Difficulty.Sign() != 0 {
+		model.Difficulty = header.Difficulty.String()
+	}
+
+	// London
+	if header.BaseFee != nil {
+		model.BaseFeePerGas = header.BaseFee.String()
+	}
+
+	// Shanghai
+	if header.WithdrawalsHash != nil {
+		model.WithdrawalsRoot = header.WithdrawalsHash.Hex()
+		model.Withdrawals = s.withdrawalsToModel(block.Withdrawals())
+	}
+
+	// Cancun
+	if header.BlobGasUsed != nil {
+		model.BlobGasUsed = strconv.FormatUint(*header.BlobGasUsed, 10)
+	}
+	if header.ExcessBlobGas != nil {
+		model.ExcessBlobGas = strconv.FormatUint(*header.ExcessBlobGas, 10)
+	}
+	if header.ParentBeaconRoot != nil {
+		model.ParentBeaconBlockRoot = header.ParentBeaconRoot.Hex()
+	}
+
+	return model
 }
 
-func (s *EthService) transactionToModel(tx *types.Transaction, blockNumber, blockHash, txIndex, status, from string) *models.Transaction {
+func (s *EthService) withdrawalsToModel(withdrawals types.Withdrawals) []models.Withdrawal {
+	result := make([]models.Withdrawal, len(withdrawals))
+	for i, w := range withdrawals {
+		result[i] = models.Withdrawal{
+			Index:          strconv.FormatUint(w.Index, 10),
+			ValidatorIndex: strconv.FormatUint(w.Validator, 10),
+			Address:        w.Address.Hex(),
+			AmountGwei:     strconv.FormatUint(w.Amount, 10),
+		}
+	}
+	return result
+}
+
+// transactionToModel converts tx to its API form. receipt is nil for
+// transactions that have not been included yet.
+func (s *EthService) transactionToModel(tx *types.Transaction, blockNumber, blockHash, txIndex string, receipt *types.Receipt, from string) *models.Transaction {
 	var to string
 	if tx.To() != nil {
 		to = tx.To().Hex()
 	}
 
+	v, r, sig := tx.RawSignatureValues()
 	model := &models.Transaction{
 		Hash:             tx.Hash().Hex(),
+		Type:             strconv.FormatUint(uint64(tx.Type()), 10),
 		BlockNumber:      blockNumber,
 		BlockHash:        blockHash,
 		TransactionIndex: txIndex,
@@ -195,15 +568,128 @@ func (s *EthService) transactionToModel(tx *types.Transaction, blockNumber, bloc
 		GasPrice:         s.weiToGwei(tx.GasPrice()),
 		Nonce:            strconv.FormatUint(tx.Nonce(), 10),
 		Input:            fmt.Sprintf("0x%x", tx.Data()),
+		V:                hexutil.EncodeBig(v),
+		R:                hexutil.EncodeBig(r),
+		S:                hexutil.EncodeBig(sig),
+	}
+
+	if chainID := tx.ChainId(); chainID != nil && chainID.Sign() > 0 {
+		model.ChainID = chainID.String()
+	}
+
+	if tx.Type() >= types.AccessListTxType {
+		model.AccessList = accessListToModel(tx.AccessList())
+	}
+
+	if tx.Type() >= types.DynamicFeeTxType {
+		model.MaxFeePerGas = s.weiToGwei(tx.GasFeeCap())
+		model.MaxPriorityFeePerGas = s.weiToGwei(tx.GasTipCap())
+	}
+
+	if tx.Type() == types.BlobTxType {
+		model.MaxFeePerBlobGas = s.weiToGwei(tx.BlobGasFeeCap())
+		model.BlobVersionedHashes = make([]string, len(tx.BlobHashes()))
+		for i, h := range tx.BlobHashes() {
+			model.BlobVersionedHashes[i] = h.Hex()
+		}
+	}
+
+	if tx.Type() == types.SetCodeTxType {
+		model.AuthorizationList = authorizationListToModel(tx.SetCodeAuthorizations())
+	}
+
+	if receipt != nil {
+		model.Status = receiptStatus(receipt)
+		model.GasUsed = strconv.FormatUint(receipt.GasUsed, 10)
+		if receipt.EffectiveGasPrice != nil {
+			model.EffectiveGasPrice = s.weiToGwei(receipt.EffectiveGasPrice)
+		}
+	}
+
+	return model
+}
+
+func accessListToModel(accessList types.AccessList) []models.AccessTuple {
+	result := make([]models.AccessTuple, len(accessList))
+	for i, tuple := range accessList {
+		storageKeys := make([]string, len(tuple.StorageKeys))
+		for j, key := range tuple.StorageKeys {
+			storageKeys[j] = key.Hex()
+		}
+		result[i] = models.AccessTuple{
+			Address:     tuple.Address.Hex(),
+			StorageKeys: storageKeys,
+		}
+	}
+	return result
+}
+
+// authorizationListToModel converts EIP-7702 authorizations, recovering the
+// authority that signed each one. Authorizations with invalid signatures are
+// reported without an authority, as nodes skip them during execution.
+func authorizationListToModel(auths []types.SetCodeAuthorization) []models.Authorization {
+	result := make([]models.Authorization, len(auths))
+	for i, auth := range auths {
+		result[i] = models.Authorization{
+			ChainID: auth.ChainID.Dec(),
+			Address: auth.Address.Hex(),
+			Nonce:   strconv.FormatUint(auth.Nonce, 10),
+			YParity: strconv.FormatUint(uint64(auth.V), 10),
+			R:       auth.R.Hex(),
+			S:       auth.S.Hex(),
+		}
+		if authority, err := auth.Authority(); err == nil {
+			result[i].Authority = authority.Hex()
+		}
+	}
+	return result
+}
+
+func (s *EthService) receiptToModel(receipt *types.Receipt) *models.Receipt {
+	model := &models.Receipt{
+		TransactionHash:   receipt.TxHash.Hex(),
+		Type:              strconv.FormatUint(uint64(receipt.Type), 10),
+		BlockNumber:       receipt.BlockNumber.String(),
+		BlockHash:         receipt.BlockHash.Hex(),
+		TransactionIndex:  strconv.FormatUint(uint64(receipt.TransactionIndex), 10),
+		Status:            receiptStatus(receipt),
+		CumulativeGasUsed: strconv.FormatUint(receipt.CumulativeGasUsed, 10),
+		GasUsed:           strconv.FormatUint(receipt.GasUsed, 10),
+		LogsBloom:         fmt.Sprintf("0x%x", receipt.Bloom.Bytes()),
+		Logs:              make([]models.EventLog, len(receipt.Logs)),
+	}
+
+	for i, vLog := range receipt.Logs {
+		model.Logs[i] = logToModel(vLog)
+	}
+
+	if receipt.ContractAddress != (common.Address{}) {
+		model.ContractAddress = receipt.ContractAddress.Hex()
 	}
 
-	if status != "" {
-		model.Status = status
+	// The fee is the execution gas plus, for blob transactions, the blob gas.
+	fee := new(big.Int)
+	if receipt.EffectiveGasPrice != nil {
+		model.EffectiveGasPrice = s.weiToGwei(receipt.EffectiveGasPrice)
+		fee.Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
 	}
+	if receipt.BlobGasPrice != nil {
+		model.BlobGasUsed = strconv.FormatUint(receipt.BlobGasUsed, 10)
+		model.BlobGasPrice = s.weiToGwei(receipt.BlobGasPrice)
+		fee.Add(fee, new(big.Int).Mul(new(big.Int).SetUint64(receipt.BlobGasUsed), receipt.BlobGasPrice))
+	}
+	model.Fee = s.weiToEther(fee)
 
 	return model
 }
 
+func receiptStatus(receipt *types.Receipt) string {
+	if receipt.Status == types.ReceiptStatusFailed {
+		return "0"
+	}
+	return "1"
+}
+
 func (s *EthService) weiToEther(wei *big.Int) string {
 	ether := new(big.Float).Quo(new(big.Float).SetInt(wei), new(big.Float).SetInt(big.NewInt(params.Ether)))
 	return ether.Text('f', 18)
@@ -215,238 +701,208 @@ func (s *EthService) weiToGwei(wei *big.Int) string {
 }
 
 // GetTransactionHistory retrieves the transaction history for a given address.
-func (s *EthService) GetTransactionHistory(address string) (*models.TransactionHistory, error) {
-	url := fmt.Sprintf("https://api.etherscan.io/api?module=account&action=txlist&address=%s&startblock=0&endblock=99999999&sort=asc&apikey=%s", address, s.etherscanAPIKey)
-
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch transaction history from Etherscan: %w", err)
+func (s *EthService) GetTransactionHistory(ctx context.Context, address string) (*models.TransactionHistory, error) {
+	if _, err := s.parseAddress("address", address); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	transactions, err := s.explorer.TransactionList(ctx, address)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	var result struct {
-		Status  string               `json:"status"`
-		Message string               `json:"message"`
-		Result  []models.Transaction `json:"result"`
-	}
-
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-
-	if result.Status != "1" {
-		return nil, fmt.Errorf("etherscan API error: %s", result.Message)
+		return nil, upstreamError("failed to fetch transaction history", err)
 	}
 
 	return &models.TransactionHistory{
 		Address:      address,
-		Transactions: result.Result,
+		Transactions: transactions,
 	}, nil
 }
 
-// GetTokenBalance retrieves the balance of a specific ERC-20 token for a given wallet address.
-func (s *EthService) GetTokenBalance(userAddress, tokenAddress string) (*models.TokenBalance, error) {
-	ctx := context.Background()
-
+// GetTokenBalance retrieves the balance of a specific ERC-20 token for a given wallet address,
+// at a block number, tag or hash, or at the latest block when block is empty.
+func (s *EthService) GetTokenBalance(ctx context.Context, userAddress, tokenAddress, block string) (*models.TokenBalance, error) {
 	// The address of the user's wallet
-	walletAddress := common.HexToAddress(userAddress)
+	walletAddress, err := s.parseAddress("address", userAddress)
+	if err != nil {
+		return nil, err
+	}
 
 	// The address of the ERC-20 token contract
-	contractAddress := common.HexToAddress(tokenAddress)
-
-	// The function signature for `balanceOf(address)` is `0x70a08231`
-	methodID := []byte{0x70, 0xa0, 0x82, 0x31}
-
-	// Pad the wallet address to 32 bytes
-	paddedAddress := common.LeftPadBytes(walletAddress.Bytes(), 32)
+	contractAddress, err := s.parseAddress("tokenAddress", tokenAddress)
+	if err != nil {
+		return nil, err
+	}
 
-	// The call data is the method ID followed by the padded address
-	var data []byte
-	data = append(data, methodID...)
-	data = append(data, paddedAddress...)
+	at, err := s.parseStateBlock("block", block)
+	if err != nil {
+		return nil, err
+	}
 
-	// Make the call to the contract
-	result, err := s.client.CallContract(ctx, ethereum.CallMsg{
+	data := append(append([]byte{}, balanceOfSelector...), common.LeftPadBytes(walletAddress.Bytes(), 32)...)
+	result, err := s.callAt(ctx, ethereum.CallMsg{
 		To:   &contractAddress,
 		Data: data,
-	}, nil)
+	}, at)
 	if err != nil {
-		return nil, fmt.Errorf("failed to call contract: %w", err)
+		return nil, stateError("failed to call contract", err)
 	}
+	balance := new(big.Int).SetBytes(result)
 
-	// The result is the balance in wei, so we convert it to a big.Int
-	balance := new(big.Int)
-	balance.SetBytes(result)
+	// The metadata only labels and formats the balance, so a failed read
+	// leaves those fields out rather than failing the request.
+	info, _ := s.tokenInfo(ctx, contractAddress)
 
 	return &models.TokenBalance{
-		Address:      userAddress,
-		TokenAddress: tokenAddress,
-		Balance:      balance.String(),
+		Address:          userAddress,
+		TokenAddress:     tokenAddress,
+		Balance:          balance.String(),
+		Name:             info.name,
+		Symbol:           info.symbol,
+		Decimals:         info.decimals,
+		FormattedBalance: info.format(balance),
 	}, nil
 }
 
-// GetTokenTransfers retrieves the ERC-20 token transfer history for a given address.
 // GetContractABI retrieves the ABI for a given smart contract address.
-func (s *EthService) GetContractABI(address string) (*models.ContractABI, error) {
-	url := fmt.Sprintf("https://api.etherscan.io/api?module=contract&action=getabi&address=%s&apikey=%s", address, s.etherscanAPIKey)
-
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch ABI from Etherscan: %w", err)
+func (s *EthService) GetContractABI(ctx context.Context, address string) (*models.ContractABI, error) {
+	if _, err := s.parseAddress("address", address); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	abi, err := s.explorer.ContractABI(ctx, address)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	var result struct {
-		Status  string `json:"status"`
-		Message string `json:"message"`
-		Result  string `json:"result"`
-	}
-
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-
-	if result.Status != "1" {
-		return nil, fmt.Errorf("etherscan API error: %s", result.Message)
+		return nil, upstreamError("failed to fetch contract ABI", err)
 	}
 
 	return &models.ContractABI{
 		Address: address,
-		ABI:     result.Result,
+		ABI:     abi,
 	}, nil
 }
 
 // GetContractSource retrieves the source code for a given smart contract address.
-func (s *EthService) GetContractSource(address string) (*models.ContractSource, error) {
-	url := fmt.Sprintf("https://api.etherscan.io/api?module=contract&action=getsourcecode&address=%s&apikey=%s", address, s.etherscanAPIKey)
-
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch source code from Etherscan: %w", err)
+func (s *EthService) GetContractSource(ctx context.Context, address string) (*models.ContractSource, error) {
+	if _, err := s.parseAddress("address", address); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	sources, err := s.explorer.ContractSource(ctx, address)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	var result struct {
-		Status  string `json:"status"`
-		Message string `json:"message"`
-		Result  []struct {
-			SourceCode string `json:"SourceCode"`
-		} `json:"result"`
-	}
-
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		return nil, upstreamError("failed to fetch contract source", err)
 	}
-
-	if result.Status != "1" || len(result.Result) == 0 {
-		return nil, fmt.Errorf("etherscan API error: %s", result.Message)
+	if len(sources) == 0 {
+		return nil, notFoundError("contract source not found", nil)
 	}
 
 	return &models.ContractSource{
 		Address:    address,
-		SourceCode: result.Result[0].SourceCode,
+		SourceCode: sources[0].SourceCode,
 	}, nil
 }
 
-func (s *EthService) GetEventLogs(address string, topics []string) ([]models.EventLog, error) {
-	ctx := context.Background()
-
-	contractAddress := common.HexToAddress(address)
-
-	var topicHashes [][]common.Hash
-	if len(topics) > 0 {
-		topicHashes = make([][]common.Hash, len(topics))
-		for i, t := range topics {
-			topicHashes[i] = []common.Hash{common.HexToHash(t)}
-		}
+// GetEventLogs retrieves one page of the logs matching q.
+func (s *EthService) GetEventLogs(ctx context.Context, q EventLogQuery) (*models.EventLogPage, error) {
+	logs, next, cursor, err := s.queryLogs(ctx, q)
+	if err != nil {
+		return nil, err
 	}
 
-	query := ethereum.FilterQuery{
-		Addresses: []common.Address{contractAddress},
-		Topics:    topicHashes,
+	page := &models.EventLogPage{Logs: []models.EventLog{}, NextCursor: next}
+	if cursor != nil {
+		page.FromBlock, page.ToBlock = &cursor.fromBlock, &cursor.toBlock
+	}
+	for _, vLog := range logs {
+		page.Logs = append(page.Logs, logToModel(&vLog))
 	}
 
-	logs, err := s.client.FilterLogs(ctx, query)
+	return page, nil
+}
+
+// GetDecodedEventLogs is GetEventLogs with each log decoded against the
+// emitting contract's ABI, falling back to a table of well-known events.
+func (s *EthService) GetDecodedEventLogs(ctx context.Context, q EventLogQuery) (*models.EventLogPage, error) {
+	logs, next, cursor, err := s.queryLogs(ctx, q)
 	if err != nil {
-		return nil, fmt.Errorf("failed to filter logs: %w", err)
+		return nil, err
+	}
+
+	// Resolve each contract's ABI once, so that unverified contracts are
+	// not looked up on the explorer for every log they emitted.
+	type resolvedABI struct {
+		abi    *abi.ABI
+		source string
 	}
+	abis := make(map[common.Address]resolvedABI)
 
-	var eventLogs []models.EventLog
+	page := &models.EventLogPage{Logs: []models.EventLog{}, NextCursor: next}
+	if cursor != nil {
+		page.FromBlock, page.ToBlock = &cursor.fromBlock, &cursor.toBlock
+	}
 	for _, vLog := range logs {
-		var logTopics []string
-		for _, t := range vLog.Topics {
-			logTopics = append(logTopics, t.Hex())
+		resolved, ok := abis[vLog.Address]
+		if !ok {
+			resolved.abi, resolved.source = s.contractABI(ctx, vLog.Address)
+			abis[vLog.Address] = resolved
 		}
 
-		eventLogs = append(eventLogs, models.EventLog{
-			Address:     vLog.Address.Hex(),
-			Topics:      logTopics,
-			Data:        fmt.Sprintf("0x%x", vLog.Data),
-			BlockNumber: vLog.BlockNumber,
-			TxHash:      vLog.TxHash.Hex(),
-			TxIndex:     vLog.TxIndex,
-			BlockHash:   vLog.BlockHash.Hex(),
-			Index:       vLog.Index,
-			Removed:     vLog.Removed,
-		})
+		eventLog := logToModel(&vLog)
+		eventLog.Decoded = decodeLog(resolved.abi, resolved.source, &vLog)
+		page.Logs = append(page.Logs, eventLog)
 	}
 
-	return eventLogs, nil
+	return page, nil
 }
 
-func (s *EthService) GetTokenTransfers(address string) ([]models.TokenTransfer, error) {
-	ctx := context.Background()
-
-	// The address to filter by
-	addr := common.HexToAddress(address)
+// decodeLog decodes vLog against contractABI, which may be nil, and then
+// against the embedded event table. Logs without topics can only match an
+// anonymous event of the contract ABI.
+func decodeLog(contractABI *abi.ABI, source string, vLog *types.Log) *models.DecodedEvent {
+	if contractABI != nil && len(vLog.Topics) > 0 {
+		if event, err := contractABI.EventByID(vLog.Topics[0]); err == nil {
+			params, err := decoder.DecodeLog(event, vLog.Topics, vLog.Data)
+			if err != nil {
+				return &models.DecodedEvent{Status: models.EventUndecodable, Event: event.Name, Signature: event.Sig, Source: source, Error: err.Error()}
+			}
+			return &models.DecodedEvent{Status: models.EventDecoded, Event: event.Name, Signature: event.Sig, Source: source, Parameters: params}
+		}
+	}
 
-	// The signature of the "Transfer" event
-	// Transfer(address,address,uint256)
-	transferEventSignature := []byte("Transfer(address,address,uint256)")
-	transferEventTopic := crypto.Keccak256Hash(transferEventSignature)
+	if contractABI != nil {
+		for _, event := range contractABI.Events {
+			if !event.Anonymous {
+				continue
+			}
+			if params, err := decoder.DecodeLog(&event, vLog.Topics, vLog.Data); err == nil {
+				return &models.DecodedEvent{Status: models.EventAnonymous, Event: event.Name, Signature: event.Sig, Source: source, Parameters: params}
+			}
+		}
+	}
 
-	// We can filter for transfers from or to the address
-	paddedAddress := common.LeftPadBytes(addr.Bytes(), 32)
-	query := ethereum.FilterQuery{
-		Topics: [][]common.Hash{
-			{transferEventTopic},
-			nil,                                 // from
-			{common.BytesToHash(paddedAddress)}, // to
-		},
+	if len(vLog.Topics) == 0 {
+		return &models.DecodedEvent{Status: models.EventAnonymous}
 	}
 
-	logs, err := s.client.FilterLogs(ctx, query)
+	event, params, err := decoder.DecodeLogBySignature(vLog.Topics, vLog.Data)
 	if err != nil {
-		return nil, fmt.Errorf("failed to filter logs: %w", err)
+		return &models.DecodedEvent{Status: models.EventUndecodable, Error: err.Error()}
 	}
+	return &models.DecodedEvent{Status: models.EventDecoded, Event: event.Name, Signature: event.Sig, Source: "signatures", Parameters: params}
+}
 
-	var transfers []models.TokenTransfer
-	for _, vLog := range logs {
-		transfer := models.TokenTransfer{
-			TokenAddress: vLog.Address.Hex(),
-			From:         common.HexToAddress(vLog.Topics[1].Hex()).Hex(),
-			To:           common.HexToAddress(vLog.Topics[2].Hex()).Hex(),
-			Value:        new(big.Int).SetBytes(vLog.Data).String(),
-			BlockHash:    vLog.BlockHash.Hex(),
-			TxHash:       vLog.TxHash.Hex(),
-		}
-		transfers = append(transfers, transfer)
+func logToModel(vLog *types.Log) models.EventLog {
+	var logTopics []string
+	for _, t := range vLog.Topics {
+		logTopics = append(logTopics, t.Hex())
 	}
 
-	return transfers, nil
+	return models.EventLog{
+		Address:     vLog.Address.Hex(),
+		Topics:      logTopics,
+		Data:        fmt.Sprintf("0x%x", vLog.Data),
+		BlockNumber: vLog.BlockNumber,
+		TxHash:      vLog.TxHash.Hex(),
+		TxIndex:     vLog.TxIndex,
+		BlockHash:   vLog.BlockHash.Hex(),
+		Index:       vLog.Index,
+		Removed:     vLog.Removed,
+	}
 }
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"eth-explorer-api/internal/explorer"
+	"eth-explorer-api/internal/explorer/explorertest"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/holiman/uint256"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr    = crypto.PubkeyToAddress(testKey.PublicKey)
+	testChainID = big.NewInt(1)
+	testTo      = common.HexToAddress("0x00000000000000000000000000000000000000b0")
+)
+
+func TestTransactionToModelTypes(t *testing.T) {
+	s := &EthService{}
+	signer := types.LatestSignerForChainID(testChainID)
+	accessList := types.AccessList{{Address: testTo, StorageKeys: []common.Hash{{0x01}}}}
+
+	t.Run("Legacy", func(t *testing.T) {
+		tx := types.MustSignNewTx(testKey, signer, &types.LegacyTx{
+			Nonce:    1,
+			GasPrice: big.NewInt(params.GWei),
+			Gas:      21000,
+			To:       &testTo,
+		})
+		model := s.transactionToModel(tx, "", "", "", nil, testAddr.Hex())
+
+		if model.Type != "0" || model.ChainID != "1" || model.MaxFeePerGas != "" || model.AccessList != nil {
+			t.Errorf("model = %+v", model)
+		}
+		if model.V != "0x25" && model.V != "0x26" {
+			t.Errorf("v = %s, want EIP-155 value", model.V)
+		}
+	})
+
+	t.Run("AccessList", func(t *testing.T) {
+		tx := types.MustSignNewTx(testKey, signer, &types.AccessListTx{
+			ChainID:    testChainID,
+			GasPrice:   big.NewInt(params.GWei),
+			Gas:        30000,
+			To:         &testTo,
+			AccessList: accessList,
+		})
+		model := s.transactionToModel(tx, "", "", "", nil, testAddr.Hex())
+
+		if model.Type != "1" || len(model.AccessList) != 1 {
+			t.Fatalf("model = %+v", model)
+		}
+		if model.AccessList[0].Address != testTo.Hex() || model.AccessList[0].StorageKeys[0] != (common.Hash{0x01}).Hex() {
+			t.Errorf("accessList = %+v", model.AccessList)
+		}
+	})
+
+	t.Run("DynamicFee", func(t *testing.T) {
+		tx := types.MustSignNewTx(testKey, signer, &types.DynamicFeeTx{
+			ChainID:   testChainID,
+			GasTipCap: big.NewInt(2 * params.GWei),
+			GasFeeCap: big.NewInt(30 * params.GWei),
+			Gas:       21000,
+			To:        &testTo,
+		})
+		receipt := &types.Receipt{Status: types.ReceiptStatusSuccessful, EffectiveGasPrice: big.NewInt(12 * params.GWei)}
+		model := s.transactionToModel(tx, "1", "0x01", "0", receipt, testAddr.Hex())
+
+		if model.Type != "2" {
+			t.Errorf("type = %s, want 2", model.Type)
+		}
+		if model.MaxFeePerGas != "30.000000000" || model.MaxPriorityFeePerGas != "2.000000000" {
+			t.Errorf("fees = %s/%s", model.MaxFeePerGas, model.MaxPriorityFeePerGas)
+		}
+		if model.EffectiveGasPrice != "12.000000000" || model.Status != "1" {
+			t.Errorf("receipt fields = %s/%s", model.EffectiveGasPrice, model.Status)
+		}
+	})
+
+	t.Run("Blob", func(t *testing.T) {
+		blobHash := common.Hash{0x01, 0x02}
+		tx := types.MustSignNewTx(testKey, signer, &types.BlobTx{
+			ChainID:    uint256.NewInt(1),
+			GasTipCap:  uint256.NewInt(params.GWei),
+			GasFeeCap:  uint256.NewInt(30 * params.GWei),
+			Gas:        21000,
+			To:         testTo,
+			BlobFeeCap: uint256.NewInt(3 * params.GWei),
+			BlobHashes: []common.Hash{blobHash},
+		})
+		model := s.transactionToModel(tx, "", "", "", nil, testAddr.Hex())
+
+		if model.Type != "3" || model.MaxFeePerBlobGas != "3.000000000" {
+			t.Errorf("model = %+v", model)
+		}
+		if len(model.BlobVersionedHashes) != 1 || model.BlobVersionedHashes[0] != blobHash.Hex() {
+			t.Errorf("blobVersionedHashes = %v", model.BlobVersionedHashes)
+		}
+	})
+
+	t.Run("SetCode", func(t *testing.T) {
+		authKey, _ := crypto.GenerateKey()
+		auth, err := types.SignSetCode(authKey, types.SetCodeAuthorization{
+			ChainID: *uint256.NewInt(1),
+			Address: testTo,
+			Nonce:   7,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx := types.MustSignNewTx(testKey, signer, &types.SetCodeTx{
+			ChainID:   uint256.NewInt(1),
+			GasTipCap: uint256.NewInt(params.GWei),
+			GasFeeCap: uint256.NewInt(30 * params.GWei),
+			Gas:       50000,
+			To:        testTo,
+			AuthList:  []types.SetCodeAuthorization{auth},
+		})
+		model := s.transactionToModel(tx, "", "", "", nil, testAddr.Hex())
+
+		if model.Type != "4" || len(model.AuthorizationList) != 1 {
+			t.Fatalf("model = %+v", model)
+		}
+		got := model.AuthorizationList[0]
+		if got.Authority != crypto.PubkeyToAddress(authKey.PublicKey).Hex() {
+			t.Errorf("authority = %s, want %s", got.Authority, crypto.PubkeyToAddress(authKey.PublicKey).Hex())
+		}
+		if got.Address != testTo.Hex() || got.Nonce != "7" || got.ChainID != "1" {
+			t.Errorf("authorization = %+v", got)
+		}
+	})
+}
+
+func TestReceiptToModel(t *testing.T) {
+	s := &EthService{}
+	contract := common.HexToAddress("0x00000000000000000000000000000000000070c0")
+
+	receipt := &types.Receipt{
+		Type:              types.BlobTxType,
+		Status:            types.ReceiptStatusSuccessful,
+		CumulativeGasUsed: 80000,
+		GasUsed:           50000,
+		EffectiveGasPrice: big.NewInt(10 * params.GWei),
+		BlobGasUsed:       params.BlobTxBlobGasPerBlob,
+		BlobGasPrice:      big.NewInt(params.GWei),
+		ContractAddress:   contract,
+		BlockNumber:       big.NewInt(5),
+		TransactionIndex:  2,
+		Logs:              []*types.Log{{Address: contract, Topics: []common.Hash{{0x01}}, Data: []byte{0xff}}},
+	}
+	model := s.receiptToModel(receipt)
+
+	if model.ContractAddress != contract.Hex() {
+		t.Errorf("contractAddress = %s, want %s", model.ContractAddress, contract.Hex())
+	}
+	if model.GasUsed != "50000" || model.CumulativeGasUsed != "80000" || model.TransactionIndex != "2" {
+		t.Errorf("gas accounting = %+v", model)
+	}
+	if model.BlobGasUsed != "131072" || model.BlobGasPrice != "1.000000000" {
+		t.Errorf("blob gas = %s at %s", model.BlobGasUsed, model.BlobGasPrice)
+	}
+	// 50000 * 10 gwei + 131072 * 1 gwei
+	if model.Fee != "0.000631072000000000" {
+		t.Errorf("fee = %s, want 0.000631072000000000", model.Fee)
+	}
+	if len(model.Logs) != 1 || model.Logs[0].Data != "0xff" {
+		t.Errorf("logs = %+v", model.Logs)
+	}
+}
+
+// blockNode serves one block and its receipts on a chain whose network ID
+// differs from its chain ID.
+type blockNode struct {
+	ChainReader
+	block    *types.Block
+	receipts []*types.Receipt
+}
+
+func (n *blockNode) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	return n.block, nil
+}
+
+func (n *blockNode) BlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error) {
+	return n.receipts, nil
+}
+
+func (n *blockNode) ChainID(ctx context.Context) (*big.Int, error) {
+	return testChainID, nil
+}
+
+func (n *blockNode) NetworkID(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(99), nil
+}
+
+func TestGetBlockWithTransactions(t *testing.T) {
+	signer := types.LatestSignerForChainID(testChainID)
+	txs := make([]*types.Transaction, 2)
+	receipts := make([]*types.Receipt, len(txs))
+	for i := range txs {
+		txs[i] = types.MustSignNewTx(testKey, signer, &types.DynamicFeeTx{
+			ChainID:   testChainID,
+			Nonce:     uint64(i),
+			GasTipCap: big.NewInt(params.GWei),
+			GasFeeCap: big.NewInt(10 * params.GWei),
+			Gas:       21000,
+			To:        &testTo,
+		})
+		receipts[i] = &types.Receipt{TxHash: txs[i].Hash(), Status: types.ReceiptStatusSuccessful, GasUsed: 21000}
+	}
+	header := &types.Header{Number: big.NewInt(5), BaseFee: big.NewInt(params.GWei)}
+	node := &blockNode{block: types.NewBlock(header, &types.Body{Transactions: txs}, nil, trie.NewStackTrie(nil))}
+	s := NewEthServiceWithClient(node, nil)
+	ctx := context.Background()
+
+	node.receipts = receipts
+	block, err := s.GetBlockWithTransactions(ctx, "5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tx := range block.Transactions {
+		if tx.From != testAddr.Hex() {
+			t.Errorf("from = %s, want %s", tx.From, testAddr.Hex())
+		}
+	}
+
+	for name, bad := range map[string][]*types.Receipt{
+		"short":      receipts[:1],
+		"misordered": {receipts[1], receipts[0]},
+	} {
+		node.receipts = bad
+		if _, err := s.GetBlockWithTransactions(ctx, "5"); !errors.Is(err, ErrUpstreamUnavailable) {
+			t.Errorf("%s receipts: err = %v, want %v", name, err, ErrUpstreamUnavailable)
+		}
+	}
+}
+
+func TestContractABIUnverifiedCache(t *testing.T) {
+	server := explorertest.NewServer()
+	defer server.Close()
+	s := NewEthServiceWithClient(nil, explorer.NewClient(server.URL, ""))
+	ctx := context.Background()
+
+	if contractABI, _ := s.contractABI(ctx, testTo); contractABI != nil {
+		t.Fatal("got an ABI for an unverified contract")
+	}
+
+	// The contract is not looked up again until the entry expires.
+	server.SetABI(testTo.Hex(), `[{"type":"function","name":"ping","inputs":[],"outputs":[]}]`)
+	if contractABI, _ := s.contractABI(ctx, testTo); contractABI != nil {
+		t.Fatal("unverified contract was looked up again")
+	}
+
+	s.unverified.expires[testTo] = time.Now().Add(-time.Second)
+	if contractABI, source := s.contractABI(ctx, testTo); contractABI == nil || source != "explorer" {
+		t.Errorf("contract ABI after expiry = %v from %q", contractABI, source)
+	}
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+
+	"eth-explorer-api/internal/models"
+	"eth-explorer-api/internal/validation"
+
+	"github.com/ethereum/go-ethereum"
+)
+
+const (
+	defaultFeeHistoryBlocks = 20
+
+	// maxFeeHistoryBlocks is the most blocks Geth returns fee history for.
+	maxFeeHistoryBlocks = 1024
+)
+
+// feePercentiles are the priority fee percentiles the slow, standard and
+// fast recommendations are based on.
+var feePercentiles = []float64{10, 50, 90}
+
+// SetFeeHistoryBlocks sets the default number of recent blocks fee
+// recommendations are based on.
+func (s *EthService) SetFeeHistoryBlocks(blocks uint64) {
+	s.feeHistoryBlocks = min(blocks, maxFeeHistoryBlocks)
+}
+
+// GetGasFees estimates EIP-1559 fees from the fee history of the last blocks
+// blocks, or of the configured window when blocks is empty. Each speed's
+// priority fee is the median, over the blocks that had transactions, of a
+// percentile of the priority fees paid in the block. Its max fee leaves
+// room for the base fee to double, which takes six full blocks.
+func (s *EthService) GetGasFees(ctx context.Context, blocks string) (*models.GasFees, error) {
+	count := s.feeHistoryBlocks
+	if blocks != "" {
+		n, err := strconv.ParseUint(blocks, 10, 64)
+		if err != nil || n < 1 || n > maxFeeHistoryBlocks {
+			return nil, invalidInputError("invalid request", &validation.FieldError{Field: "blocks", Value: blocks, Reason: fmt.Sprintf("must be between 1 and %d", maxFeeHistoryBlocks)})
+		}
+		count = n
+	}
+
+	history, err := s.client.FeeHistory(ctx, count, nil, feePercentiles)
+	if err != nil {
+		return nil, upstreamError("failed to fetch fee history", err)
+	}
+	if len(history.BaseFee) < 2 {
+		return nil, upstreamError("failed to fetch fee history", fmt.Errorf("got %d base fees, want at least 2", len(history.BaseFee)))
+	}
+
+	// The last base fee is the one of the next block.
+	baseFee := history.BaseFee[len(history.BaseFee)-2]
+	nextBaseFee := history.BaseFee[len(history.BaseFee)-1]
+	fees := &models.GasFees{
+		BlockNumber:    history.OldestBlock.Uint64() + uint64(len(history.BaseFee)) - 2,
+		Blocks:         len(history.BaseFee) - 1,
+		BaseFee:        s.weiToGwei(baseFee),
+		BaseFeeWei:     baseFee.String(),
+		NextBaseFee:    s.weiToGwei(nextBaseFee),
+		NextBaseFeeWei: nextBaseFee.String(),
+	}
+
+	tips, err := s.priorityFees(ctx, history)
+	if err != nil {
+		return nil, err
+	}
+	maxBaseFee := new(big.Int).Mul(nextBaseFee, big.NewInt(2))
+	for i, rec := range []*models.FeeRecommendation{&fees.Slow, &fees.Standard, &fees.Fast} {
+		maxFee := new(big.Int).Add(maxBaseFee, tips[i])
+		*rec = models.FeeRecommendation{
+			Percentile:              feePercentiles[i],
+			MaxPriorityFeePerGas:    s.weiToGwei(tips[i]),
+			MaxPriorityFeePerGasWei: tips[i].String(),
+			MaxFeePerGas:            s.weiToGwei(maxFee),
+			MaxFeePerGasWei:         maxFee.String(),
+		}
+	}
+
+	blobBaseFee, err := s.client.BlobBaseFee(ctx)
+	switch {
+	case err == nil:
+		fees.BlobBaseFee = s.weiToGwei(blobBaseFee)
+		fees.BlobBaseFeeWei = blobBaseFee.String()
+	case !isMethodNotFound(err):
+		return nil, upstreamError("failed to fetch blob base fee", err)
+	}
+
+	return fees, nil
+}
+
+// priorityFees returns the median of each reward percentile over the blocks
+// with transactions. When none of the blocks had any, every speed gets the
+// node's suggested tip.
+func (s *EthService) priorityFees(ctx context.Context, history *ethereum.FeeHistory) ([]*big.Int, error) {
+	tips := make([]*big.Int, len(feePercentiles))
+	for i := range feePercentiles {
+		var rewards []*big.Int
+		for block, reward := range history.Reward {
+			if history.GasUsedRatio[block] > 0 && i < len(reward) {
+				rewards = append(rewards, reward[i])
+			}
+		}
+		if len(rewards) == 0 {
+			suggested, err := s.client.SuggestGasTipCap(ctx)
+			if err != nil {
+				return nil, upstreamError("failed to fetch suggested priority fee", err)
+			}
+			for j := range tips {
+				tips[j] = suggested
+			}
+			return tips, nil
+		}
+
+		slices.SortFunc(rewards, func(a, b *big.Int) int { return a.Cmp(b) })
+		tips[i] = rewards[len(rewards)/2]
+	}
+	return tips, nil
+}
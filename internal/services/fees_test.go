@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"eth-explorer-api/internal/models"
+
+	"github.com/ethereum/go-ethereum"
+)
+
+// feeNode serves a fixed fee history and has no eth_blobBaseFee.
+type feeNode struct {
+	ChainReader
+	history *ethereum.FeeHistory
+	tip     *big.Int
+}
+
+func (n *feeNode) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	return n.history, nil
+}
+
+func (n *feeNode) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return n.tip, nil
+}
+
+func (n *feeNode) BlobBaseFee(ctx context.Context) (*big.Int, error) {
+	return nil, rpcError{rpcMethodNotFoundCode}
+}
+
+func gwei(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e9))
+}
+
+func TestGetGasFees(t *testing.T) {
+	node := &feeNode{tip: gwei(1), history: &ethereum.FeeHistory{
+		OldestBlock: big.NewInt(100),
+		BaseFee:     []*big.Int{gwei(10), gwei(11), gwei(12), gwei(13)},
+		// The empty second block does not count towards the medians.
+		GasUsedRatio: []float64{0.5, 0, 0.9},
+		Reward: [][]*big.Int{
+			{gwei(1), gwei(2), gwei(9)},
+			{gwei(0), gwei(0), gwei(0)},
+			{gwei(3), gwei(4), gwei(5)},
+		},
+	}}
+	s := NewEthServiceWithClient(node, nil)
+
+	fees, err := s.GetGasFees(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fees.BlockNumber != 102 || fees.Blocks != 3 || fees.BaseFeeWei != gwei(12).String() || fees.NextBaseFeeWei != gwei(13).String() {
+		t.Errorf("fees = %+v", fees)
+	}
+	if fees.BlobBaseFee != "" {
+		t.Errorf("blob_base_fee = %s, want none", fees.BlobBaseFee)
+	}
+
+	// Upper medians of {1, 3}, {2, 4} and {9, 5}; max fees add twice the
+	// next base fee.
+	for name, tt := range map[string]struct {
+		rec      models.FeeRecommendation
+		tip, max *big.Int
+	}{
+		"slow":     {fees.Slow, gwei(3), gwei(29)},
+		"standard": {fees.Standard, gwei(4), gwei(30)},
+		"fast":     {fees.Fast, gwei(9), gwei(35)},
+	} {
+		if tt.rec.MaxPriorityFeePerGasWei != tt.tip.String() || tt.rec.MaxFeePerGasWei != tt.max.String() {
+			t.Errorf("%s = %+v, want tip %s and max fee %s", name, tt.rec, tt.tip, tt.max)
+		}
+	}
+	if fees.Fast.MaxFeePerGas != "35.000000000" || fees.Fast.Percentile != 90 {
+		t.Errorf("fast = %+v", fees.Fast)
+	}
+
+	// Without transactions in the window, every speed uses the suggested tip.
+	node.history.GasUsedRatio = []float64{0, 0, 0}
+	fees, err = s.GetGasFees(context.Background(), "3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fees.Slow.MaxPriorityFeePerGasWei != gwei(1).String() || fees.Fast.MaxPriorityFeePerGasWei != gwei(1).String() {
+		t.Errorf("tips = %s, %s, want the suggested tip", fees.Slow.MaxPriorityFeePerGasWei, fees.Fast.MaxPriorityFeePerGasWei)
+	}
+
+	for _, blocks := range []string{"0", "1025", "ten"} {
+		if _, err := s.GetGasFees(context.Background(), blocks); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("blocks %q: error = %v, want invalid input", blocks, err)
+		}
+	}
+}
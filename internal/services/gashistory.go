@@ -0,0 +1,450 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"eth-explorer-api/internal/models"
+	"eth-explorer-api/internal/validation"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	defaultGasHistoryBlocks = 100
+
+	// defaultGasHistoryPollInterval replaces a poll interval that is not
+	// positive.
+	defaultGasHistoryPollInterval = 4 * time.Second
+
+	// gasHistorySyncTimeout bounds one update of the gas history, including
+	// the backfill when tracking starts.
+	gasHistorySyncTimeout = 2 * time.Minute
+
+	// headerBatchSize is the number of headers requested per JSON-RPC batch.
+	headerBatchSize = 100
+)
+
+// gasHistoryPercentiles are the priority fee percentiles recorded for each
+// block of the gas history.
+var gasHistoryPercentiles = []float64{10, 25, 50, 75, 90}
+
+// gasHistoryBuckets are the periods gas history points can be aggregated
+// over.
+var gasHistoryBuckets = map[string]time.Duration{
+	"minute": time.Minute,
+	"hour":   time.Hour,
+}
+
+// gasSample is the gas usage and fees of one block.
+type gasSample struct {
+	number       uint64
+	hash         common.Hash
+	time         uint64
+	baseFee      *big.Int
+	gasUsedRatio float64
+	rewards      []*big.Int
+}
+
+// gasHistory is a ring buffer of the gas samples of consecutive blocks,
+// oldest first.
+type gasHistory struct {
+	mu      sync.RWMutex
+	samples []gasSample
+	next    int
+	count   int
+}
+
+func newGasHistory(size uint64) *gasHistory {
+	return &gasHistory{samples: make([]gasSample, size)}
+}
+
+// push appends a sample, evicting the oldest one when the buffer is full.
+func (h *gasHistory) push(sample gasSample) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.samples[h.next] = sample
+	h.next = (h.next + 1) % len(h.samples)
+	h.count = min(h.count+1, len(h.samples))
+}
+
+// last returns the newest sample.
+func (h *gasHistory) last() (gasSample, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if h.count == 0 {
+		return gasSample{}, false
+	}
+	return h.samples[(h.next-1+len(h.samples))%len(h.samples)], true
+}
+
+// dropLast removes the newest sample, for a block that was reorganised out.
+func (h *gasHistory) dropLast() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.count > 0 {
+		h.next = (h.next - 1 + len(h.samples)) % len(h.samples)
+		h.count--
+	}
+}
+
+// reset empties the buffer.
+func (h *gasHistory) reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.next, h.count = 0, 0
+}
+
+// recent returns up to n of the newest samples, oldest first.
+func (h *gasHistory) recent(n int) []gasSample {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	n = min(n, h.count)
+	samples := make([]gasSample, n)
+	start := h.next - n + len(h.samples)
+	for i := range samples {
+		samples[i] = h.samples[(start+i)%len(h.samples)]
+	}
+	return samples
+}
+
+// StartGasHistory starts recording the gas usage and fees of the last size
+// blocks. The history is backfilled and then kept up to date in the
+// background, from new head notifications when the node connection supports
+// subscriptions and by polling every pollInterval otherwise, until ctx is
+// done. A failed backfill is logged and retried on the next head.
+func (s *EthService) StartGasHistory(ctx context.Context, size uint64, pollInterval time.Duration) {
+	if pollInterval <= 0 {
+		pollInterval = defaultGasHistoryPollInterval
+	}
+	s.gasHistory = newGasHistory(max(size, 1))
+	go s.trackGasHistory(ctx, pollInterval)
+}
+
+// trackGasHistory backfills the gas history and then updates it on every
+// new head until ctx is done. Subscriptions that fail are re-established on
+// the next poll.
+func (s *EthService) trackGasHistory(ctx context.Context, pollInterval time.Duration) {
+	if err := s.syncGasHistory(ctx); err != nil && ctx.Err() == nil {
+		log.Printf("Gas history: failed to backfill: %v", err)
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	heads := make(chan *types.Header, 16)
+	canSubscribe := true
+	var sub ethereum.Subscription
+	var subErr <-chan error
+	defer func() {
+		if sub != nil {
+			sub.Unsubscribe()
+		}
+	}()
+
+	for {
+		if sub == nil && canSubscribe {
+			var err error
+			if sub, err = s.client.SubscribeNewHead(ctx, heads); err != nil {
+				sub = nil
+				if errors.Is(err, rpc.ErrNotificationsUnsupported) {
+					canSubscribe = false
+				} else if ctx.Err() == nil {
+					log.Printf("Gas history: failed to subscribe to new heads, polling instead: %v", err)
+				}
+			} else {
+				subErr = sub.Err()
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-heads:
+		case err := <-subErr:
+			log.Printf("Gas history: new head subscription failed: %v", err)
+			sub, subErr = nil, nil
+		case <-ticker.C:
+			if sub != nil {
+				continue
+			}
+		}
+
+		if err := s.syncGasHistory(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Gas history: %v", err)
+		}
+	}
+}
+
+// syncGasHistory appends the blocks up to the current head to the gas
+// history. Blocks that are no longer canonical are dropped first.
+func (s *EthService) syncGasHistory(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, gasHistorySyncTimeout)
+	defer cancel()
+
+	head, err := s.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to fetch head: %w", err)
+	}
+	headNumber := head.Number.Uint64()
+
+	history := s.gasHistory
+	from := uint64(0)
+	if size := uint64(len(history.samples)); headNumber >= size {
+		from = headNumber - size + 1
+	}
+	for {
+		last, ok := history.last()
+		if !ok {
+			break
+		}
+		if last.number+1 < from {
+			// Too far behind the head to keep consecutive blocks.
+			history.reset()
+			break
+		}
+		header, err := s.client.HeaderByNumber(ctx, new(big.Int).SetUint64(last.number))
+		if err != nil && !errors.Is(err, ethereum.NotFound) {
+			return fmt.Errorf("failed to fetch block %d: %w", last.number, err)
+		}
+		if err == nil && header.Hash() == last.hash {
+			from = last.number + 1
+			break
+		}
+		history.dropLast()
+	}
+
+	chunk := uint64(maxFeeHistoryBlocks)
+	for from <= headNumber {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		to := min(headNumber, from+chunk-1)
+		samples, err := s.gasSamples(ctx, from, to)
+		if err != nil {
+			return err
+		}
+		if len(samples) == 0 {
+			return fmt.Errorf("no fee history for blocks %d to %d", from, to)
+		}
+		if samples[0].number != from {
+			// The node caps the blocks per request below what was asked,
+			// so retry with its cap. A node whose fee history lags its head
+			// returns other blocks without capping; the next head or poll
+			// picks up from here instead.
+			if uint64(len(samples)) >= chunk {
+				return fmt.Errorf("fee history for blocks %d to %d starts at block %d", from, to, samples[0].number)
+			}
+			chunk = uint64(len(samples))
+			continue
+		}
+		for _, sample := range samples {
+			history.push(sample)
+		}
+		from = samples[len(samples)-1].number + 1
+	}
+	return nil
+}
+
+// gasSamples returns the gas samples of the blocks from to to. The node may
+// return fewer blocks than asked for.
+func (s *EthService) gasSamples(ctx context.Context, from, to uint64) ([]gasSample, error) {
+	history, err := s.client.FeeHistory(ctx, to-from+1, new(big.Int).SetUint64(to), gasHistoryPercentiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch fee history: %w", err)
+	}
+	oldest := history.OldestBlock.Uint64()
+	if len(history.GasUsedRatio) == 0 {
+		return nil, nil
+	}
+	if len(history.BaseFee) < len(history.GasUsedRatio) {
+		return nil, fmt.Errorf("fee history has %d base fees for %d blocks", len(history.BaseFee), len(history.GasUsedRatio))
+	}
+
+	numbers := make([]uint64, len(history.GasUsedRatio))
+	for i := range numbers {
+		numbers[i] = oldest + uint64(i)
+	}
+	headers, err := s.blockHeaders(ctx, numbers)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]gasSample, len(history.GasUsedRatio))
+	for i := range samples {
+		samples[i] = gasSample{
+			number:       oldest + uint64(i),
+			hash:         headers[i].Hash,
+			time:         uint64(headers[i].Timestamp),
+			baseFee:      history.BaseFee[i],
+			gasUsedRatio: history.GasUsedRatio[i],
+		}
+		if i < len(history.Reward) {
+			samples[i].rewards = history.Reward[i]
+		}
+	}
+	return samples, nil
+}
+
+// blockHeader holds the header fields the gas history and transfer
+// timestamps use.
+type blockHeader struct {
+	Hash      common.Hash    `json:"hash"`
+	Timestamp hexutil.Uint64 `json:"timestamp"`
+}
+
+// blockHeaders returns the hashes and timestamps of the blocks numbered
+// numbers, in batches of JSON-RPC requests when the node connection allows
+// it and one header at a time otherwise.
+func (s *EthService) blockHeaders(ctx context.Context, numbers []uint64) ([]blockHeader, error) {
+	headers := make([]blockHeader, len(numbers))
+
+	client, ok := s.rpcClient()
+	if !ok {
+		for i, number := range numbers {
+			header, err := s.client.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch block %d: %w", number, err)
+			}
+			headers[i] = blockHeader{Hash: header.Hash(), Timestamp: hexutil.Uint64(header.Time)}
+		}
+		return headers, nil
+	}
+
+	for start := 0; start < len(headers); start += headerBatchSize {
+		batch := make([]rpc.BatchElem, min(headerBatchSize, len(headers)-start))
+		results := make([]*blockHeader, len(batch))
+		for i := range batch {
+			batch[i] = rpc.BatchElem{
+				Method: "eth_getBlockByNumber",
+				Args:   []interface{}{hexutil.EncodeUint64(numbers[start+i]), false},
+				Result: &results[i],
+			}
+		}
+		if err := client.BatchCallContext(ctx, batch); err != nil {
+			return nil, fmt.Errorf("failed to fetch blocks: %w", err)
+		}
+		for i, elem := range batch {
+			number := numbers[start+i]
+			if elem.Error != nil {
+				return nil, fmt.Errorf("failed to fetch block %d: %w", number, elem.Error)
+			}
+			if results[i] == nil {
+				return nil, fmt.Errorf("failed to fetch block %d: %w", number, ethereum.NotFound)
+			}
+			headers[start+i] = *results[i]
+		}
+	}
+	return headers, nil
+}
+
+// GetGasHistory returns the base fee, gas used ratio and priority fee
+// percentiles of the last blocks recorded blocks, one point per block or,
+// with bucket "minute" or "hour", one per period. Buckets average the base
+// fee and gas used ratio and take the median of each priority fee
+// percentile over the blocks that had transactions.
+func (s *EthService) GetGasHistory(ctx context.Context, blocks, bucket string) (*models.GasHistory, error) {
+	if s.gasHistory == nil {
+		return nil, unsupportedError("gas history is not being recorded", nil)
+	}
+
+	size := len(s.gasHistory.samples)
+	count := min(defaultGasHistoryBlocks, size)
+	if blocks != "" {
+		n, err := strconv.Atoi(blocks)
+		if err != nil || n < 1 || n > size {
+			return nil, invalidInputError("invalid request", &validation.FieldError{Field: "blocks", Value: blocks, Reason: fmt.Sprintf("must be between 1 and %d", size)})
+		}
+		count = n
+	}
+	period := time.Duration(0)
+	if bucket != "" {
+		var ok bool
+		if period, ok = gasHistoryBuckets[bucket]; !ok {
+			return nil, invalidInputError("invalid request", &validation.FieldError{Field: "bucket", Value: bucket, Reason: "must be minute or hour"})
+		}
+	}
+
+	history := &models.GasHistory{
+		Bucket:      bucket,
+		Percentiles: gasHistoryPercentiles,
+		Points:      []models.GasHistoryPoint{},
+	}
+	samples := s.gasHistory.recent(count)
+	for start := 0; start < len(samples); {
+		end := start + 1
+		if period > 0 {
+			bucketStart := blockTime(samples[start]).Truncate(period)
+			for end < len(samples) && blockTime(samples[end]).Truncate(period).Equal(bucketStart) {
+				end++
+			}
+		}
+		history.Points = append(history.Points, s.gasHistoryPoint(samples[start:end], period))
+		start = end
+	}
+	return history, nil
+}
+
+func blockTime(sample gasSample) time.Time {
+	return time.Unix(int64(sample.time), 0).UTC()
+}
+
+// gasHistoryPoint aggregates the samples of consecutive blocks. A period of
+// zero means a single block, whose own time the point carries.
+func (s *EthService) gasHistoryPoint(samples []gasSample, period time.Duration) models.GasHistoryPoint {
+	first, last := samples[0], samples[len(samples)-1]
+	point := models.GasHistoryPoint{
+		Timestamp:       blockTime(first),
+		FromBlock:       first.number,
+		ToBlock:         last.number,
+		Blocks:          len(samples),
+		PriorityFees:    make([]string, len(gasHistoryPercentiles)),
+		PriorityFeesWei: make([]string, len(gasHistoryPercentiles)),
+	}
+	if period > 0 {
+		point.Timestamp = point.Timestamp.Truncate(period)
+	}
+
+	baseFee := new(big.Int)
+	for _, sample := range samples {
+		baseFee.Add(baseFee, sample.baseFee)
+		point.GasUsedRatio += sample.gasUsedRatio
+	}
+	baseFee.Quo(baseFee, big.NewInt(int64(len(samples))))
+	point.BaseFee = s.weiToGwei(baseFee)
+	point.BaseFeeWei = baseFee.String()
+	point.GasUsedRatio /= float64(len(samples))
+
+	for i := range gasHistoryPercentiles {
+		var rewards []*big.Int
+		for _, sample := range samples {
+			if sample.gasUsedRatio > 0 && i < len(sample.rewards) {
+				rewards = append(rewards, sample.rewards[i])
+			}
+		}
+		reward := new(big.Int)
+		if len(rewards) > 0 {
+			slices.SortFunc(rewards, func(a, b *big.Int) int { return a.Cmp(b) })
+			reward = rewards[len(rewards)/2]
+		}
+		point.PriorityFees[i] = s.weiToGwei(reward)
+		point.PriorityFeesWei[i] = reward.String()
+	}
+	return point
+}
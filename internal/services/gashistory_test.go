@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// chainNode serves headers and fee history for an in-memory chain with a
+// block every 12 seconds. Blocks with gas used paid priority fees of 1 to 5
+// gwei at the recorded percentiles. It returns fee history for at most
+// maxBlocks blocks per call when set, and ending no later than lag blocks
+// before the head.
+type chainNode struct {
+	ChainReader
+	headers   []*types.Header
+	maxBlocks uint64
+	lag       uint64
+}
+
+func (n *chainNode) extend(count int, fork byte, gasUsed uint64) {
+	for i := 0; i < count; i++ {
+		number := uint64(len(n.headers))
+		n.headers = append(n.headers, &types.Header{
+			Number:   new(big.Int).SetUint64(number),
+			Time:     number * 12,
+			GasLimit: 30_000_000,
+			GasUsed:  gasUsed,
+			BaseFee:  gwei(int64(10 + number)),
+			Extra:    []byte{fork},
+		})
+	}
+}
+
+func (n *chainNode) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if number == nil {
+		return n.headers[len(n.headers)-1], nil
+	}
+	if number.Uint64() >= uint64(len(n.headers)) {
+		return nil, ethereum.NotFound
+	}
+	return n.headers[number.Uint64()], nil
+}
+
+func (n *chainNode) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	if n.maxBlocks > 0 {
+		blockCount = min(blockCount, n.maxBlocks)
+	}
+	last := min(lastBlock.Uint64(), uint64(len(n.headers))-1-n.lag)
+	history := &ethereum.FeeHistory{OldestBlock: new(big.Int).SetUint64(last - blockCount + 1)}
+	for _, header := range n.headers[last-blockCount+1 : last+1] {
+		history.BaseFee = append(history.BaseFee, header.BaseFee)
+		history.GasUsedRatio = append(history.GasUsedRatio, float64(header.GasUsed)/float64(header.GasLimit))
+		rewards := make([]*big.Int, len(rewardPercentiles))
+		for i := range rewards {
+			rewards[i] = new(big.Int)
+			if header.GasUsed > 0 {
+				rewards[i] = gwei(int64(i + 1))
+			}
+		}
+		history.Reward = append(history.Reward, rewards)
+	}
+	history.BaseFee = append(history.BaseFee, gwei(99))
+	return history, nil
+}
+
+func (n *chainNode) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return nil, rpc.ErrNotificationsUnsupported
+}
+
+// recorded returns the block numbers in the gas history and checks that
+// their hashes are those of the node's chain.
+func recorded(t *testing.T, s *EthService, node *chainNode) []uint64 {
+	t.Helper()
+
+	var numbers []uint64
+	for _, sample := range s.gasHistory.recent(len(s.gasHistory.samples)) {
+		if sample.hash != node.headers[sample.number].Hash() {
+			t.Errorf("block %d has a stale hash", sample.number)
+		}
+		numbers = append(numbers, sample.number)
+	}
+	return numbers
+}
+
+func TestSyncGasHistory(t *testing.T) {
+	ctx := context.Background()
+	node := &chainNode{maxBlocks: 3}
+	node.extend(10, 0, 15_000_000)
+	s := NewEthServiceWithClient(node, nil)
+	s.gasHistory = newGasHistory(8)
+
+	if err := s.syncGasHistory(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := recorded(t, s, node); len(got) != 8 || got[0] != 2 || got[7] != 9 {
+		t.Fatalf("backfilled blocks = %v, want 2 to 9", got)
+	}
+
+	node.extend(2, 0, 15_000_000)
+	if err := s.syncGasHistory(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := recorded(t, s, node); got[0] != 4 || got[7] != 11 {
+		t.Fatalf("blocks = %v, want 4 to 11", got)
+	}
+
+	// Blocks 10 and 11 are reorganised out and replaced.
+	node.headers = node.headers[:10]
+	node.extend(3, 1, 0)
+	if err := s.syncGasHistory(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := recorded(t, s, node); got[0] != 5 || got[7] != 12 {
+		t.Fatalf("blocks = %v, want 5 to 12", got)
+	}
+	if last, _ := s.gasHistory.last(); last.gasUsedRatio != 0 {
+		t.Errorf("block 12 gas used ratio = %v, want the new fork's 0", last.gasUsedRatio)
+	}
+
+	// A head further ahead than the buffer holds starts it over.
+	node.extend(20, 1, 0)
+	if err := s.syncGasHistory(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := recorded(t, s, node); len(got) != 8 || got[0] != 25 || got[7] != 32 {
+		t.Fatalf("blocks = %v, want 25 to 32", got)
+	}
+}
+
+func TestSyncGasHistoryLaggingNode(t *testing.T) {
+	node := &chainNode{lag: 2}
+	node.extend(10, 0, 15_000_000)
+	s := NewEthServiceWithClient(node, nil)
+	s.gasHistory = newGasHistory(8)
+
+	if err := s.syncGasHistory(context.Background()); err == nil {
+		t.Fatal("sync against a lagging node succeeded")
+	}
+	if got := recorded(t, s, node); len(got) != 0 {
+		t.Errorf("blocks = %v, want none", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	node.lag = 0
+	if err := s.syncGasHistory(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestGetGasHistory(t *testing.T) {
+	ctx := context.Background()
+	node := &chainNode{}
+	node.extend(10, 0, 15_000_000)
+	s := NewEthServiceWithClient(node, nil)
+
+	if _, err := s.GetGasHistory(ctx, "", ""); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("error = %v, want unsupported before tracking starts", err)
+	}
+
+	s.gasHistory = newGasHistory(8)
+	if err := s.syncGasHistory(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := s.GetGasHistory(ctx, "3", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Points) != 3 || len(history.Percentiles) != 5 {
+		t.Fatalf("history = %+v", history)
+	}
+	point := history.Points[2]
+	if point.FromBlock != 9 || point.ToBlock != 9 || point.BaseFeeWei != gwei(19).String() || point.GasUsedRatio != 0.5 {
+		t.Errorf("point = %+v", point)
+	}
+	if point.PriorityFeesWei[0] != gwei(1).String() || point.PriorityFees[4] != "5.000000000" {
+		t.Errorf("priority fees = %v", point.PriorityFeesWei)
+	}
+	if point.Timestamp.Unix() != 108 {
+		t.Errorf("timestamp = %v, want block 9's", point.Timestamp)
+	}
+
+	// Blocks 2 to 4 fall in the first minute and 5 to 9 in the second.
+	history, err = s.GetGasHistory(ctx, "8", "minute")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Points) != 2 {
+		t.Fatalf("points = %+v", history.Points)
+	}
+	first, second := history.Points[0], history.Points[1]
+	if first.FromBlock != 2 || first.ToBlock != 4 || first.Blocks != 3 || first.BaseFeeWei != gwei(13).String() {
+		t.Errorf("first minute = %+v", first)
+	}
+	if second.Blocks != 5 || second.Timestamp.Unix() != 60 || second.BaseFeeWei != gwei(17).String() {
+		t.Errorf("second minute = %+v", second)
+	}
+
+	for _, tt := range []struct{ blocks, bucket string }{{"0", ""}, {"9", ""}, {"ten", ""}, {"", "day"}} {
+		if _, err := s.GetGasHistory(ctx, tt.blocks, tt.bucket); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("blocks %q bucket %q: error = %v, want invalid input", tt.blocks, tt.bucket, err)
+		}
+	}
+}
@@ -0,0 +1,363 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"eth-explorer-api/internal/validation"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// defaultLogChunkSize is the number of blocks requested per
+	// eth_getLogs call unless SetLogChunkSize overrides it.
+	defaultLogChunkSize = 2000
+
+	// maxLogChunksPerPage bounds the eth_getLogs calls made for one page.
+	// A page that hits it is returned short, with a cursor to resume from.
+	maxLogChunksPerPage = 50
+
+	defaultLogLimit = 100
+	maxLogLimit     = 1000
+)
+
+// EventLogQuery holds the raw parameters of an event log request. FromBlock
+// and ToBlock accept block numbers or tags and are mutually exclusive with
+// BlockHash. Cursor is the NextCursor of a previous page.
+type EventLogQuery struct {
+	// Address is a contract address or a comma-separated list of them.
+	Address string
+
+	// Topics matches one exact topic per position. TopicFilters is the
+	// alternative, per-position form: each entry is a comma-separated list
+	// of topics any of which may match, and an empty entry matches any
+	// topic. The two cannot be combined.
+	Topics       []string
+	TopicFilters [4]string
+
+	FromBlock string
+	ToBlock   string
+	BlockHash string
+	Limit     string
+	Cursor    string
+}
+
+// logCursor is the position of the first log of the next page and, for
+// logs that yield several results such as ERC-1155 TransferBatch events,
+// the first result of that log. It also carries the range being scanned,
+// so that every page reports the same range and a scan up to "latest" does
+// not grow between pages.
+type logCursor struct {
+	block     uint64
+	index     uint
+	item      uint
+	fromBlock uint64
+	toBlock   uint64
+}
+
+func (c logCursor) String() string {
+	raw := fmt.Sprintf("%d.%d.%d.%d.%d", c.block, c.index, c.item, c.fromBlock, c.toBlock)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func parseLogCursor(value string) (logCursor, error) {
+	var c logCursor
+	if value == "" {
+		return c, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err == nil {
+		_, err = fmt.Sscanf(string(raw), "%d.%d.%d.%d.%d", &c.block, &c.index, &c.item, &c.fromBlock, &c.toBlock)
+	}
+	if err != nil || c.fromBlock > c.block || c.block > c.toBlock {
+		return logCursor{}, invalidInputError("invalid request", &validation.FieldError{Field: "cursor", Value: value, Reason: "is not a valid cursor"})
+	}
+	return c, nil
+}
+
+// includes reports whether l is at or after the cursor position.
+func (c logCursor) includes(l *types.Log) bool {
+	return l.BlockNumber > c.block || (l.BlockNumber == c.block && l.Index >= c.index)
+}
+
+// SetLogChunkSize sets the number of blocks requested per eth_getLogs call.
+// Ranges are split into chunks of this size and chunks are halved when the
+// node reports too many results.
+func (s *EthService) SetLogChunkSize(blocks uint64) {
+	if blocks > 0 {
+		s.logChunkSize = blocks
+	}
+}
+
+// queryLogs returns one page of the logs matching q, along with the cursor
+// of the next page, or "" when the range is exhausted, and the cursor the
+// page started from, whose range it reports. The latter is nil for block
+// hash queries.
+func (s *EthService) queryLogs(ctx context.Context, q EventLogQuery) ([]types.Log, string, *logCursor, error) {
+	var addresses []common.Address
+	for _, value := range strings.Split(q.Address, ",") {
+		addr, err := s.parseAddress("address", strings.TrimSpace(value))
+		if err != nil {
+			return nil, "", nil, err
+		}
+		addresses = append(addresses, addr)
+	}
+
+	topics, err := s.parseTopicFilters(q)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	limit, err := parseLogLimit(q.Limit)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	cursor, err := parseLogCursor(q.Cursor)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	filter := ethereum.FilterQuery{
+		Addresses: addresses,
+		Topics:    topics,
+	}
+
+	if q.BlockHash != "" {
+		if q.FromBlock != "" || q.ToBlock != "" {
+			return nil, "", nil, invalidInputError("invalid request", &validation.FieldError{Field: "blockHash", Value: q.BlockHash, Reason: "cannot be combined with fromBlock or toBlock"})
+		}
+		hash, err := s.parseHash("blockHash", q.BlockHash)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		filter.BlockHash = &hash
+
+		logs, err := s.client.FilterLogs(ctx, filter)
+		if err != nil {
+			return nil, "", nil, upstreamError("failed to filter logs", err)
+		}
+		page, next := paginateLogs(logs, cursor, limit)
+		if next != nil {
+			next.fromBlock, next.toBlock = next.block, next.block
+			return page, next.String(), nil, nil
+		}
+		return page, "", nil, nil
+	}
+
+	if q.Cursor == "" {
+		if cursor, err = s.resolveLogRange(ctx, q.FromBlock, q.ToBlock); err != nil {
+			return nil, "", nil, err
+		}
+	}
+
+	logs, next, err := s.scanLogs(ctx, logFilter{queries: []ethereum.FilterQuery{filter}}, cursor, limit)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if next != nil {
+		return logs, next.String(), &cursor, nil
+	}
+	return logs, "", &cursor, nil
+}
+
+// resolveLogRange returns a cursor at the start of the range fromBlock to
+// toBlock. toBlock defaults to "latest"; without fromBlock only the most
+// recent chunk is scanned, which the cursor's fromBlock reflects.
+func (s *EthService) resolveLogRange(ctx context.Context, fromBlock, toBlock string) (logCursor, error) {
+	var cursor logCursor
+	var err error
+	if cursor.toBlock, err = s.resolveBlockNumber(ctx, "toBlock", toBlock); err != nil {
+		return logCursor{}, err
+	}
+
+	if fromBlock == "" {
+		if cursor.toBlock >= s.logChunkSize {
+			cursor.block = cursor.toBlock - s.logChunkSize + 1
+		}
+	} else if cursor.block, err = s.resolveBlockNumber(ctx, "fromBlock", fromBlock); err != nil {
+		return logCursor{}, err
+	}
+	cursor.fromBlock = cursor.block
+
+	if cursor.block > cursor.toBlock {
+		return logCursor{}, invalidInputError("invalid request", &validation.FieldError{Field: "fromBlock", Value: fromBlock, Reason: "is after toBlock"})
+	}
+	return cursor, nil
+}
+
+// parseTopicFilters converts the topic filters of q to the positional form
+// of ethereum.FilterQuery, where a nil position matches any topic.
+func (s *EthService) parseTopicFilters(q EventLogQuery) ([][]common.Hash, error) {
+	var topics [][]common.Hash
+	for _, value := range q.Topics {
+		topic, err := s.parseHash("topics", value)
+		if err != nil {
+			return nil, err
+		}
+		topics = append(topics, []common.Hash{topic})
+	}
+
+	for i, filter := range q.TopicFilters {
+		if filter == "" {
+			continue
+		}
+		field := "topic" + strconv.Itoa(i)
+		if len(q.Topics) > 0 {
+			return nil, invalidInputError("invalid request", &validation.FieldError{Field: field, Value: filter, Reason: "cannot be combined with topics"})
+		}
+
+		for len(topics) <= i {
+			topics = append(topics, nil)
+		}
+		for _, value := range strings.Split(filter, ",") {
+			topic, err := s.parseHash(field, strings.TrimSpace(value))
+			if err != nil {
+				return nil, err
+			}
+			topics[i] = append(topics[i], topic)
+		}
+	}
+
+	return topics, nil
+}
+
+// logFilter selects the logs matched by any of its queries. match, if set,
+// drops logs the queries cannot exclude on their own.
+type logFilter struct {
+	queries []ethereum.FilterQuery
+	match   func(*types.Log) bool
+}
+
+// scanLogs collects up to limit logs from cursor through cursor.toBlock,
+// splitting the range into chunks of at most s.logChunkSize blocks. Chunks
+// the node rejects as too large are halved and retried. It returns the
+// cursor of the first log not collected, or nil when the range is
+// exhausted.
+func (s *EthService) scanLogs(ctx context.Context, filter logFilter, cursor logCursor, limit int) ([]types.Log, *logCursor, error) {
+	chunkSize := s.logChunkSize
+	from := cursor.block
+	var logs []types.Log
+
+	for chunks := 0; from <= cursor.toBlock; chunks++ {
+		if chunks == maxLogChunksPerPage {
+			return logs, &logCursor{block: from, fromBlock: cursor.fromBlock, toBlock: cursor.toBlock}, nil
+		}
+
+		to := cursor.toBlock
+		if to-from >= chunkSize {
+			to = from + chunkSize - 1
+		}
+
+		found, err := s.filterChunk(ctx, filter, from, to)
+		if err != nil {
+			if isTooManyResults(err) && to > from {
+				chunkSize = (to - from + 1) / 2
+				continue
+			}
+			return nil, nil, upstreamError("failed to filter logs", err)
+		}
+
+		page, next := paginateLogs(found, cursor, limit-len(logs))
+		logs = append(logs, page...)
+		if next != nil {
+			return logs, next, nil
+		}
+
+		from = to + 1
+	}
+
+	return logs, nil, nil
+}
+
+// filterChunk runs every query of filter over the blocks from through to
+// and returns the matching logs in chain order, without duplicates.
+func (s *EthService) filterChunk(ctx context.Context, filter logFilter, from, to uint64) ([]types.Log, error) {
+	var logs []types.Log
+	for _, query := range filter.queries {
+		query.FromBlock = new(big.Int).SetUint64(from)
+		query.ToBlock = new(big.Int).SetUint64(to)
+
+		found, err := s.client.FilterLogs(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, found...)
+	}
+
+	if len(filter.queries) > 1 {
+		sort.SliceStable(logs, func(i, j int) bool {
+			if logs[i].BlockNumber != logs[j].BlockNumber {
+				return logs[i].BlockNumber < logs[j].BlockNumber
+			}
+			return logs[i].Index < logs[j].Index
+		})
+	}
+
+	matched := logs[:0]
+	for i := range logs {
+		if i > 0 && logs[i].BlockNumber == logs[i-1].BlockNumber && logs[i].Index == logs[i-1].Index {
+			continue
+		}
+		if filter.match != nil && !filter.match(&logs[i]) {
+			continue
+		}
+		matched = append(matched, logs[i])
+	}
+	return matched, nil
+}
+
+// paginateLogs returns up to limit of the logs at or after cursor, and the
+// cursor of the first one left over, within the same range as cursor.
+func paginateLogs(logs []types.Log, cursor logCursor, limit int) ([]types.Log, *logCursor) {
+	var page []types.Log
+	for i := range logs {
+		if !cursor.includes(&logs[i]) {
+			continue
+		}
+		if len(page) == limit {
+			return page, &logCursor{block: logs[i].BlockNumber, index: logs[i].Index, fromBlock: cursor.fromBlock, toBlock: cursor.toBlock}
+		}
+		page = append(page, logs[i])
+	}
+	return page, nil
+}
+
+func parseLogLimit(value string) (int, error) {
+	if value == "" {
+		return defaultLogLimit, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > maxLogLimit {
+		return 0, invalidInputError("invalid request", &validation.FieldError{Field: "limit", Value: value, Reason: fmt.Sprintf("must be between 1 and %d", maxLogLimit)})
+	}
+	return limit, nil
+}
+
+// resolveBlockNumber parses a block number or tag, looking up the number
+// the tag currently refers to. An empty value means "latest".
+func (s *EthService) resolveBlockNumber(ctx context.Context, field, value string) (uint64, error) {
+	if value == "" {
+		value = "latest"
+	}
+	num, tag, err := s.parseBlockID(field, value)
+	if err != nil {
+		return 0, err
+	}
+	if tag == "" {
+		return num.Uint64(), nil
+	}
+
+	header, err := s.client.HeaderByNumber(ctx, num)
+	if err != nil {
+		return 0, upstreamError("failed to resolve "+field, err)
+	}
+	return header.Number.Uint64(), nil
+}
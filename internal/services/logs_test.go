@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// logNode serves FilterLogs from a fixed set of logs and, like hosted
+// providers, rejects ranges wider than maxRange blocks.
+type logNode struct {
+	ChainReader
+	logs     []types.Log
+	head     uint64
+	maxRange uint64
+	calls    int
+}
+
+func (n *logNode) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	n.calls++
+	from, to := q.FromBlock.Uint64(), q.ToBlock.Uint64()
+	if to-from+1 > n.maxRange {
+		return nil, errors.New("query returned more than 10000 results")
+	}
+
+	var logs []types.Log
+	for _, l := range n.logs {
+		if l.BlockNumber >= from && l.BlockNumber <= to {
+			logs = append(logs, l)
+		}
+	}
+	return logs, nil
+}
+
+func (n *logNode) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: new(big.Int).SetUint64(n.head)}, nil
+}
+
+func TestQueryLogsPagination(t *testing.T) {
+	node := &logNode{head: 10_000, maxRange: 300}
+	for block := uint64(0); block <= node.head; block += 500 {
+		for index := uint(0); index < 3; index++ {
+			node.logs = append(node.logs, types.Log{BlockNumber: block, Index: index})
+		}
+	}
+
+	s := NewEthServiceWithClient(node, nil)
+	s.SetLogChunkSize(1000)
+
+	q := EventLogQuery{Address: testTo.Hex(), FromBlock: "0", Limit: "4"}
+	var got []types.Log
+	for pages := 0; ; pages++ {
+		if pages > len(node.logs) {
+			t.Fatal("pagination does not terminate")
+		}
+		logs, next, _, err := s.queryLogs(context.Background(), q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(logs) > 4 {
+			t.Fatalf("page of %d logs, want at most 4", len(logs))
+		}
+		got = append(got, logs...)
+		if next == "" {
+			break
+		}
+		q.Cursor = next
+	}
+
+	if len(got) != len(node.logs) {
+		t.Fatalf("got %d logs, want %d", len(got), len(node.logs))
+	}
+	for i := range got {
+		if got[i].BlockNumber != node.logs[i].BlockNumber || got[i].Index != node.logs[i].Index {
+			t.Fatalf("log %d = %d/%d, want %d/%d", i, got[i].BlockNumber, got[i].Index, node.logs[i].BlockNumber, node.logs[i].Index)
+		}
+	}
+}
+
+func TestEventLogsReportRange(t *testing.T) {
+	node := &logNode{head: 10_000, maxRange: 1000, logs: []types.Log{{BlockNumber: 9500}, {BlockNumber: 9600}}}
+	s := NewEthServiceWithClient(node, nil)
+	s.SetLogChunkSize(1000)
+
+	// Without fromBlock only the last chunk is scanned, and every page says
+	// so.
+	q := EventLogQuery{Address: testTo.Hex(), Limit: "1"}
+	for i := 0; i < 2; i++ {
+		page, err := s.GetEventLogs(context.Background(), q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Logs) != 1 || page.FromBlock == nil || *page.FromBlock != 9001 || *page.ToBlock != node.head {
+			t.Fatalf("page %d = %+v, want one log in 9001 to %d", i, page, node.head)
+		}
+		q.Cursor = page.NextCursor
+	}
+}
+
+func TestScanLogsShrinksChunks(t *testing.T) {
+	node := &logNode{head: 999, maxRange: 100, logs: []types.Log{{BlockNumber: 998}}}
+	s := NewEthServiceWithClient(node, nil)
+
+	logs, next, err := s.scanLogs(context.Background(), logFilter{queries: []ethereum.FilterQuery{{}}}, logCursor{toBlock: node.head}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 || next != nil {
+		t.Errorf("logs = %d, next = %v", len(logs), next)
+	}
+	// 2000 -> 1000 -> 500 -> 250 -> 125 -> 62 blocks per call.
+	if node.calls > 25 {
+		t.Errorf("calls = %d, chunks are not shrinking", node.calls)
+	}
+}
+
+func TestScanLogsChunkBudget(t *testing.T) {
+	node := &logNode{head: 1_000_000, maxRange: 1000}
+	s := NewEthServiceWithClient(node, nil)
+	s.SetLogChunkSize(1000)
+
+	logs, next, err := s.scanLogs(context.Background(), logFilter{queries: []ethereum.FilterQuery{{}}}, logCursor{toBlock: node.head}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 0 || node.calls != maxLogChunksPerPage {
+		t.Errorf("logs = %d, calls = %d", len(logs), node.calls)
+	}
+	if next == nil || next.block != maxLogChunksPerPage*1000 || next.toBlock != node.head {
+		t.Errorf("next = %+v", next)
+	}
+}
+
+func TestFilterChunkMergesQueries(t *testing.T) {
+	// logNode ignores topics, so both queries return every log.
+	node := &logNode{head: 10, maxRange: 100, logs: []types.Log{
+		{BlockNumber: 1, Index: 0, Topics: make([]common.Hash, 3)},
+		{BlockNumber: 1, Index: 1, Topics: make([]common.Hash, 4)},
+		{BlockNumber: 2, Index: 0, Topics: make([]common.Hash, 3)},
+	}}
+	s := NewEthServiceWithClient(node, nil)
+
+	filter := logFilter{
+		queries: []ethereum.FilterQuery{{}, {}},
+		match:   func(l *types.Log) bool { return len(l.Topics) == 3 },
+	}
+	logs, err := s.filterChunk(context.Background(), filter, 0, node.head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 2 || logs[0].BlockNumber != 1 || logs[1].BlockNumber != 2 {
+		t.Errorf("logs = %+v", logs)
+	}
+}
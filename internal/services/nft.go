@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"eth-explorer-api/internal/models"
+	"eth-explorer-api/internal/validation"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// NFT standards.
+const (
+	StandardERC721  = "erc721"
+	StandardERC1155 = "erc1155"
+)
+
+var (
+	transferSingleTopic = crypto.Keccak256Hash([]byte("TransferSingle(address,address,address,uint256,uint256)"))
+	transferBatchTopic  = crypto.Keccak256Hash([]byte("TransferBatch(address,address,address,uint256[],uint256[])"))
+
+	uint256SliceType, _ = abi.NewType("uint256[]", "", nil)
+	transferBatchData   = abi.Arguments{{Type: uint256SliceType}, {Type: uint256SliceType}}
+)
+
+// NFT function selectors.
+var (
+	supportsInterfaceSelector = []byte{0x01, 0xff, 0xc9, 0xa7}
+	ownerOfSelector           = []byte{0x63, 0x52, 0x21, 0x1e}
+	tokenURISelector          = []byte{0xc8, 0x7b, 0x56, 0xdd}
+	uriSelector               = []byte{0x0e, 0x89, 0x34, 0x1c}
+)
+
+// nftInterfaces are the ERC-165 interface IDs reported by GetNFT, in the
+// order they are listed.
+var nftInterfaces = []struct {
+	name string
+	id   [4]byte
+}{
+	{"erc721", [4]byte{0x80, 0xac, 0x58, 0xcd}},
+	{"erc721_metadata", [4]byte{0x5b, 0x5e, 0x13, 0x9f}},
+	{"erc721_enumerable", [4]byte{0x78, 0x0e, 0x9d, 0x63}},
+	{"erc1155", [4]byte{0xd9, 0xb6, 0x7a, 0x26}},
+	{"erc1155_metadata_uri", [4]byte{0x0e, 0x89, 0x34, 0x1c}},
+	{"erc2981", [4]byte{0x2a, 0x55, 0x20, 0x5a}},
+}
+
+// GetNFT retrieves a token of an ERC-721 or ERC-1155 contract: the
+// standard and the ERC-165 interfaces the contract supports, the collection
+// name and symbol, the owner of ERC-721 tokens and the token's metadata
+// URI. All calls are batched through Multicall3 and read the state at block,
+// or at the latest block when block is empty.
+func (s *EthService) GetNFT(ctx context.Context, contractAddress, tokenID, block string) (*models.NFT, error) {
+	contract, err := s.parseAddress("contract", contractAddress)
+	if err != nil {
+		return nil, err
+	}
+	id, err := validation.Uint256("tokenId", tokenID)
+	if err != nil {
+		return nil, invalidInputError("invalid request", err)
+	}
+	idWord := common.LeftPadBytes(id.Bytes(), 32)
+	at, err := s.parseStateBlock("block", block)
+	if err != nil {
+		return nil, err
+	}
+
+	supportsInterface := func(id [4]byte) call3 {
+		data := append(append([]byte{}, supportsInterfaceSelector...), common.RightPadBytes(id[:], 32)...)
+		return call3{Target: contract, AllowFailure: true, CallData: data}
+	}
+	withID := func(selector []byte) call3 {
+		return call3{Target: contract, AllowFailure: true, CallData: append(append([]byte{}, selector...), idWord...)}
+	}
+
+	// A contract supports ERC-165 if it claims its own interface ID but
+	// not the invalid ID 0xffffffff.
+	calls := []call3{
+		supportsInterface([4]byte{0x01, 0xff, 0xc9, 0xa7}),
+		supportsInterface([4]byte{0xff, 0xff, 0xff, 0xff}),
+	}
+	for _, iface := range nftInterfaces {
+		calls = append(calls, supportsInterface(iface.id))
+	}
+	calls = append(calls,
+		withID(ownerOfSelector),
+		withID(tokenURISelector),
+		withID(uriSelector),
+		call3{Target: contract, AllowFailure: true, CallData: nameSelector},
+		call3{Target: contract, AllowFailure: true, CallData: symbolSelector},
+	)
+
+	results, err := s.multicall3(ctx, calls, at)
+	if err != nil {
+		return nil, err
+	}
+	rest := results[2+len(nftInterfaces):]
+	ownerOf, tokenURI, uri, name, symbol := rest[0], rest[1], rest[2], rest[3], rest[4]
+
+	nft := &models.NFT{
+		Contract:   contract.Hex(),
+		TokenID:    id.String(),
+		Interfaces: []string{},
+		Name:       decodeTokenString(resultData(name)),
+		Symbol:     decodeTokenString(resultData(symbol)),
+	}
+
+	supported := make(map[string]bool)
+	if resultBool(results[0]) && !resultBool(results[1]) {
+		nft.Interfaces = append(nft.Interfaces, "erc165")
+		for i, iface := range nftInterfaces {
+			if resultBool(results[2+i]) {
+				supported[iface.name] = true
+				nft.Interfaces = append(nft.Interfaces, iface.name)
+			}
+		}
+	}
+
+	owner, hasOwner := resultAddress(ownerOf)
+	switch {
+	case supported["erc721"]:
+		nft.Standard = StandardERC721
+	case supported["erc1155"]:
+		nft.Standard = StandardERC1155
+	case hasOwner:
+		// ERC-721 contracts that predate ERC-165.
+		nft.Standard = StandardERC721
+	default:
+		return nil, notFoundError("NFT contract not found", nil)
+	}
+
+	if nft.Standard == StandardERC721 {
+		if !hasOwner {
+			return nil, notFoundError("token not found", nil)
+		}
+		nft.Owner = owner.Hex()
+		nft.TokenURI = decodeTokenString(resultData(tokenURI))
+	} else {
+		// ERC-1155 URIs may contain {id}, to be replaced by the token ID as
+		// 64 lowercase hex digits.
+		nft.TokenURI = strings.ReplaceAll(decodeTokenString(resultData(uri)), "{id}", fmt.Sprintf("%064x", id))
+	}
+
+	return nft, nil
+}
+
+// resultBool decodes a bool return value, treating anything but an ABI
+// encoded true as false.
+func resultBool(r call3Result) bool {
+	return r.Success && len(r.ReturnData) == 32 && new(big.Int).SetBytes(r.ReturnData).Cmp(big.NewInt(1)) == 0
+}
+
+// resultAddress decodes an address return value.
+func resultAddress(r call3Result) (common.Address, bool) {
+	if !r.Success || len(r.ReturnData) != 32 || !isZero(r.ReturnData[:12]) {
+		return common.Address{}, false
+	}
+	return common.BytesToAddress(r.ReturnData[12:]), true
+}
+
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// GetNFTTransfers retrieves one page of the ERC-721 and ERC-1155 transfers
+// sent or received by an address. A TransferBatch log yields one transfer
+// per token ID, and a page may end partway through one.
+func (s *EthService) GetNFTTransfers(ctx context.Context, q TokenTransferQuery) (*models.NFTTransferPage, error) {
+	scan, err := s.parseTransferQuery(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	// ERC-721 Transfer logs have four topics, which tells them apart from
+	// ERC-20 transfers; ERC-1155 logs index the operator before from and
+	// to.
+	queries := scan.queries([]common.Hash{transferTopic}, 1)
+	queries = append(queries, scan.queries([]common.Hash{transferSingleTopic, transferBatchTopic}, 2)...)
+	filter := logFilter{
+		queries: queries,
+		match:   func(l *types.Log) bool { return len(l.Topics) == 4 },
+	}
+	logs, next, err := s.scanLogs(ctx, filter, scan.cursor, scan.limit)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamps, err := s.blockTimestamps(ctx, logs)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.NFTTransferPage{
+		Transfers: []models.NFTTransfer{},
+		FromBlock: scan.cursor.fromBlock,
+		ToBlock:   scan.cursor.toBlock,
+	}
+	// limit counts transfers, and each log yields at least one unless it
+	// is malformed, so the logs of the page are enough to fill it.
+logs:
+	for i, vLog := range logs {
+		transfers := nftTransfers(&vLog)
+		item := uint(0)
+		if i == 0 && vLog.BlockNumber == scan.cursor.block && vLog.Index == scan.cursor.index {
+			item = scan.cursor.item
+		}
+		for ; item < uint(len(transfers)); item++ {
+			if len(page.Transfers) == scan.limit {
+				next = &logCursor{block: vLog.BlockNumber, index: vLog.Index, item: item, fromBlock: scan.cursor.fromBlock, toBlock: scan.cursor.toBlock}
+				break logs
+			}
+			transfer := transfers[item]
+			from, to := common.HexToAddress(transfer.From), common.HexToAddress(transfer.To)
+			transfer.Direction = transferDirection(scan.owner, from, to)
+			transfer.Timestamp = timestamps[vLog.BlockNumber]
+			page.Transfers = append(page.Transfers, transfer)
+		}
+	}
+	if next != nil {
+		page.NextCursor = next.String()
+	}
+
+	return page, nil
+}
+
+// nftTransfers decodes an ERC-721 Transfer or ERC-1155 TransferSingle or
+// TransferBatch log. Logs with malformed data yield no transfers.
+func nftTransfers(vLog *types.Log) []models.NFTTransfer {
+	base := models.NFTTransfer{
+		TokenAddress: vLog.Address.Hex(),
+		BlockNumber:  vLog.BlockNumber,
+		BlockHash:    vLog.BlockHash.Hex(),
+		TxHash:       vLog.TxHash.Hex(),
+		LogIndex:     vLog.Index,
+	}
+	topicAddress := func(i int) string { return common.BytesToAddress(vLog.Topics[i].Bytes()).Hex() }
+
+	switch vLog.Topics[0] {
+	case transferTopic:
+		base.Standard = StandardERC721
+		base.From, base.To = topicAddress(1), topicAddress(2)
+		base.TokenID = vLog.Topics[3].Big().String()
+		base.Amount = "1"
+		return []models.NFTTransfer{base}
+
+	case transferSingleTopic:
+		if len(vLog.Data) != 64 {
+			return nil
+		}
+		base.Standard = StandardERC1155
+		base.Operator, base.From, base.To = topicAddress(1), topicAddress(2), topicAddress(3)
+		base.TokenID = new(big.Int).SetBytes(vLog.Data[:32]).String()
+		base.Amount = new(big.Int).SetBytes(vLog.Data[32:]).String()
+		return []models.NFTTransfer{base}
+
+	case transferBatchTopic:
+		values, err := transferBatchData.Unpack(vLog.Data)
+		if err != nil {
+			return nil
+		}
+		ids, amounts := values[0].([]*big.Int), values[1].([]*big.Int)
+		if len(ids) != len(amounts) {
+			return nil
+		}
+		base.Standard = StandardERC1155
+		base.Operator, base.From, base.To = topicAddress(1), topicAddress(2), topicAddress(3)
+		transfers := make([]models.NFTTransfer, len(ids))
+		for i := range ids {
+			transfers[i] = base
+			transfers[i].TokenID = ids[i].String()
+			transfers[i].Amount = amounts[i].String()
+		}
+		return transfers
+	}
+	return nil
+}
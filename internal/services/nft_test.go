@@ -0,0 +1,161 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	nftContract = common.HexToAddress("0x00000000000000000000000000000000000000c0")
+	nftOwner    = common.HexToAddress("0x00000000000000000000000000000000000000d0")
+)
+
+// nftContractCode answers like an ERC-1155 contract when erc1155 is set and
+// like an ERC-721 contract that owns only token 7 otherwise.
+func nftContractCode(erc1155 bool) func(common.Address, []byte) []byte {
+	word := func(b ...byte) []byte { return common.LeftPadBytes(b, 32) }
+	str := func(v string) []byte {
+		packed, _ := abi.Arguments{{Type: stringType}}.Pack(v)
+		return packed
+	}
+
+	return func(_ common.Address, data []byte) []byte {
+		switch {
+		case bytes.HasPrefix(data, supportsInterfaceSelector):
+			switch id := [4]byte(data[4:8]); {
+			case id == [4]byte{0x01, 0xff, 0xc9, 0xa7},
+				!erc1155 && id == [4]byte{0x80, 0xac, 0x58, 0xcd},
+				!erc1155 && id == [4]byte{0x5b, 0x5e, 0x13, 0x9f},
+				erc1155 && id == [4]byte{0xd9, 0xb6, 0x7a, 0x26}:
+				return word(1)
+			}
+			return word(0)
+		case !erc1155 && bytes.HasPrefix(data, ownerOfSelector):
+			if new(big.Int).SetBytes(data[4:]).Int64() != 7 {
+				return nil
+			}
+			return common.LeftPadBytes(nftOwner.Bytes(), 32)
+		case !erc1155 && bytes.HasPrefix(data, tokenURISelector):
+			return str("ipfs://token/7")
+		case erc1155 && bytes.HasPrefix(data, uriSelector):
+			return str("https://example.com/{id}.json")
+		case bytes.Equal(data, nameSelector):
+			return str("Collection")
+		}
+		return nil
+	}
+}
+
+func TestGetNFT(t *testing.T) {
+	node := &multicallNode{contract: nftContractCode(false)}
+	s := NewEthServiceWithClient(node, nil)
+
+	nft, err := s.GetNFT(context.Background(), nftContract.Hex(), "7", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nft.Standard != StandardERC721 || nft.Owner != nftOwner.Hex() || nft.TokenURI != "ipfs://token/7" || nft.Name != "Collection" {
+		t.Errorf("nft = %+v", nft)
+	}
+	if len(nft.Interfaces) != 3 || nft.Interfaces[0] != "erc165" || nft.Interfaces[1] != "erc721" || nft.Interfaces[2] != "erc721_metadata" {
+		t.Errorf("interfaces = %v", nft.Interfaces)
+	}
+	if node.calls != 1 {
+		t.Errorf("calls = %d, want a single aggregate3 call", node.calls)
+	}
+
+	// ownerOf returns nothing for a token that does not exist.
+	if _, err := s.GetNFT(context.Background(), nftContract.Hex(), "8", ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing token error = %v, want not found", err)
+	}
+}
+
+func TestGetNFTERC1155(t *testing.T) {
+	s := NewEthServiceWithClient(&multicallNode{contract: nftContractCode(true)}, nil)
+
+	nft, err := s.GetNFT(context.Background(), nftContract.Hex(), "0x2a", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "https://example.com/000000000000000000000000000000000000000000000000000000000000002a.json"
+	if nft.Standard != StandardERC1155 || nft.Owner != "" || nft.TokenURI != want {
+		t.Errorf("nft = %+v", nft)
+	}
+}
+
+func TestNFTTransfers(t *testing.T) {
+	addrTopic := func(a common.Address) common.Hash { return common.BytesToHash(a.Bytes()) }
+	batchData, err := transferBatchData.Pack([]*big.Int{big.NewInt(1), big.NewInt(2)}, []*big.Int{big.NewInt(10), big.NewInt(20)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node := &logNode{head: 100, maxRange: 1000, logs: []types.Log{
+		// ERC-20 transfer, which has no indexed token ID.
+		{BlockNumber: 1, Index: 0, Topics: []common.Hash{transferTopic, addrTopic(nftOwner), addrTopic(testTo)}, Data: common.LeftPadBytes([]byte{1}, 32)},
+		// ERC-721 transfer of token 7.
+		{BlockNumber: 1, Index: 1, Topics: []common.Hash{transferTopic, addrTopic(nftOwner), addrTopic(testTo), common.BigToHash(big.NewInt(7))}},
+		// ERC-1155 single and batch transfers.
+		{BlockNumber: 2, Index: 0, Topics: []common.Hash{transferSingleTopic, addrTopic(testTo), addrTopic(testTo), addrTopic(nftOwner)}, Data: append(common.LeftPadBytes([]byte{3}, 32), common.LeftPadBytes([]byte{5}, 32)...)},
+		{BlockNumber: 2, Index: 1, Topics: []common.Hash{transferBatchTopic, addrTopic(testTo), addrTopic(nftOwner), addrTopic(testTo)}, Data: batchData},
+	}}
+	s := NewEthServiceWithClient(node, nil)
+
+	page, err := s.GetNFTTransfers(context.Background(), TokenTransferQuery{Address: testTo.Hex(), FromBlock: "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct{ standard, tokenID, amount, direction string }{
+		{StandardERC721, "7", "1", DirectionIn},
+		{StandardERC1155, "3", "5", DirectionOut},
+		{StandardERC1155, "1", "10", DirectionIn},
+		{StandardERC1155, "2", "20", DirectionIn},
+	}
+	if len(page.Transfers) != len(want) {
+		t.Fatalf("transfers = %+v", page.Transfers)
+	}
+	for i, w := range want {
+		got := page.Transfers[i]
+		if got.Standard != w.standard || got.TokenID != w.tokenID || got.Amount != w.amount || got.Direction != w.direction {
+			t.Errorf("transfers[%d] = %+v, want %+v", i, got, w)
+		}
+	}
+	if page.Transfers[1].Operator != testTo.Hex() {
+		t.Errorf("operator = %s, want %s", page.Transfers[1].Operator, testTo.Hex())
+	}
+
+	// The limit counts transfers, so a page can end inside a batch.
+	q := TokenTransferQuery{Address: testTo.Hex(), FromBlock: "0", Limit: "3"}
+	var ids []string
+	for pages := 0; ; pages++ {
+		if pages > len(want) {
+			t.Fatal("pagination does not terminate")
+		}
+		page, err := s.GetNFTTransfers(context.Background(), q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Transfers) > 3 {
+			t.Fatalf("page of %d transfers, want at most 3", len(page.Transfers))
+		}
+		for _, transfer := range page.Transfers {
+			ids = append(ids, transfer.TokenID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+	if strings.Join(ids, ",") != "7,3,1,2" {
+		t.Errorf("token IDs = %v, want 7,3,1,2", ids)
+	}
+}
@@ -0,0 +1,320 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"eth-explorer-api/internal/models"
+	"eth-explorer-api/internal/validation"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultMulticall3Address is where Multicall3 is deployed on Ethereum
+// mainnet, its testnets and most EVM chains.
+var DefaultMulticall3Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+// maxMulticallBatch bounds the calls aggregated into one eth_call, keeping
+// it under the node's gas cap.
+const maxMulticallBatch = 500
+
+const multicall3ABIJSON = `[{"type":"function","name":"aggregate3","stateMutability":"payable",
+	"inputs":[{"name":"calls","type":"tuple[]","components":[
+		{"name":"target","type":"address"},
+		{"name":"allowFailure","type":"bool"},
+		{"name":"callData","type":"bytes"}]}],
+	"outputs":[{"name":"returnData","type":"tuple[]","components":[
+		{"name":"success","type":"bool"},
+		{"name":"returnData","type":"bytes"}]}]}]`
+
+var multicall3ABI = mustParseABI(multicall3ABIJSON)
+
+func mustParseABI(raw string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(raw))
+	if err != nil {
+		panic("services: bad ABI: " + err.Error())
+	}
+	return parsed
+}
+
+// call3 is a Multicall3 Call3: a call that may fail without failing the
+// batch.
+type call3 struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// call3Result is a Multicall3 Result.
+type call3Result struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// TokenListEntry is a token in a token list file, in the format of the
+// Uniswap token lists. ChainID and the metadata fields are optional.
+type TokenListEntry struct {
+	ChainID  uint64 `json:"chainId"`
+	Address  string `json:"address"`
+	Name     string `json:"name"`
+	Symbol   string `json:"symbol"`
+	Decimals *uint8 `json:"decimals"`
+}
+
+// LoadTokenList reads a token list file: either a token list object with a
+// "tokens" array or a bare array of entries.
+func LoadTokenList(path string) ([]TokenListEntry, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var list struct {
+		Tokens []TokenListEntry `json:"tokens"`
+	}
+	if err := json.Unmarshal(raw, &list); err != nil {
+		if err := json.Unmarshal(raw, &list.Tokens); err != nil {
+			return nil, fmt.Errorf("%s: not a token list: %w", path, err)
+		}
+	}
+
+	for _, entry := range list.Tokens {
+		if !common.IsHexAddress(entry.Address) {
+			return nil, fmt.Errorf("%s: invalid token address %q", path, entry.Address)
+		}
+	}
+	return list.Tokens, nil
+}
+
+// SetTokenList sets the tokens a portfolio covers when the request does
+// not list any. Entries for other chains are ignored.
+func (s *EthService) SetTokenList(entries []TokenListEntry) {
+	s.tokenList = entries
+}
+
+// SetMulticallAddress sets the address of the Multicall3 contract used to
+// batch calls.
+func (s *EthService) SetMulticallAddress(address common.Address) {
+	s.multicall = address
+}
+
+// GetPortfolio retrieves the ETH balance of an address and its non-zero
+// balances of the given comma-separated tokens, or of the configured token
+// list when tokens is empty. Balances are read in batches through
+// Multicall3, at block or at the latest block when block is empty.
+func (s *EthService) GetPortfolio(ctx context.Context, address, tokens, block string) (*models.Portfolio, error) {
+	owner, err := s.parseAddress("address", address)
+	if err != nil {
+		return nil, err
+	}
+	at, err := s.parseStateBlock("block", block)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenAddresses, err := s.portfolioTokens(ctx, tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := s.balanceAt(ctx, owner, at)
+	if err != nil {
+		return nil, err
+	}
+
+	balanceOf := append(append([]byte{}, balanceOfSelector...), common.LeftPadBytes(owner.Bytes(), 32)...)
+	calls := make([]call3, len(tokenAddresses))
+	for i, token := range tokenAddresses {
+		calls[i] = call3{Target: token, AllowFailure: true, CallData: balanceOf}
+	}
+	results, err := s.multicall3(ctx, calls, at)
+	if err != nil {
+		return nil, err
+	}
+
+	portfolio := &models.Portfolio{
+		Address:    owner.Hex(),
+		Balance:    s.weiToEther(balance),
+		BalanceWei: balance.String(),
+		Tokens:     []models.TokenBalance{},
+	}
+
+	var held []common.Address
+	balances := make(map[common.Address]*big.Int)
+	for i, result := range results {
+		token := tokenAddresses[i]
+		if !result.Success || len(result.ReturnData) < 32 {
+			portfolio.FailedTokens = append(portfolio.FailedTokens, token.Hex())
+			continue
+		}
+		if amount := new(big.Int).SetBytes(result.ReturnData[:32]); amount.Sign() > 0 {
+			held = append(held, token)
+			balances[token] = amount
+		}
+	}
+
+	infos, err := s.tokenInfos(ctx, held)
+	if err != nil {
+		return nil, err
+	}
+	for _, token := range held {
+		info := infos[token]
+		portfolio.Tokens = append(portfolio.Tokens, models.TokenBalance{
+			Address:          owner.Hex(),
+			TokenAddress:     token.Hex(),
+			Balance:          balances[token].String(),
+			Name:             info.name,
+			Symbol:           info.symbol,
+			Decimals:         info.decimals,
+			FormattedBalance: info.format(balances[token]),
+		})
+	}
+
+	return portfolio, nil
+}
+
+// portfolioTokens parses the requested tokens, or falls back to the
+// configured token list for the node's chain.
+func (s *EthService) portfolioTokens(ctx context.Context, tokens string) ([]common.Address, error) {
+	var addresses []common.Address
+	seen := make(map[common.Address]bool)
+	add := func(token common.Address) {
+		if !seen[token] {
+			seen[token] = true
+			addresses = append(addresses, token)
+		}
+	}
+
+	if tokens != "" {
+		for _, value := range strings.Split(tokens, ",") {
+			token, err := s.parseAddress("tokens", strings.TrimSpace(value))
+			if err != nil {
+				return nil, err
+			}
+			add(token)
+		}
+		return addresses, nil
+	}
+
+	if len(s.tokenList) == 0 {
+		return nil, invalidInputError("invalid request", &validation.FieldError{Field: "tokens", Reason: "is required when no default token list is configured"})
+	}
+
+	chainID, err := s.client.ChainID(ctx)
+	if err != nil {
+		return nil, upstreamError("failed to get chain ID", err)
+	}
+	for _, entry := range s.tokenList {
+		if entry.ChainID != 0 && entry.ChainID != chainID.Uint64() {
+			continue
+		}
+		token := common.HexToAddress(entry.Address)
+		add(token)
+
+		// Metadata from the list saves looking it up on chain.
+		if entry.Symbol != "" && entry.Decimals != nil {
+			if _, ok := s.tokens.get(token); !ok {
+				s.tokens.add(token, tokenInfo{name: entry.Name, symbol: entry.Symbol, decimals: entry.Decimals})
+			}
+		}
+	}
+	return addresses, nil
+}
+
+// tokenInfos returns the metadata of tokens, reading the ones not yet
+// cached from the latest block through Multicall3.
+func (s *EthService) tokenInfos(ctx context.Context, tokens []common.Address) (map[common.Address]tokenInfo, error) {
+	infos := make(map[common.Address]tokenInfo)
+	var missing []common.Address
+	var calls []call3
+	for _, token := range tokens {
+		if info, ok := s.tokens.get(token); ok {
+			infos[token] = info
+			continue
+		}
+		missing = append(missing, token)
+		calls = append(calls,
+			call3{Target: token, AllowFailure: true, CallData: nameSelector},
+			call3{Target: token, AllowFailure: true, CallData: symbolSelector},
+			call3{Target: token, AllowFailure: true, CallData: decimalsSelector},
+		)
+	}
+	if len(calls) == 0 {
+		return infos, nil
+	}
+
+	results, err := s.multicall3(ctx, calls, stateBlock{})
+	if err != nil {
+		return nil, err
+	}
+	for i, token := range missing {
+		info := newTokenInfo(resultData(results[3*i]), resultData(results[3*i+1]), resultData(results[3*i+2]))
+		s.tokens.add(token, info)
+		infos[token] = info
+	}
+	return infos, nil
+}
+
+// multicall3 runs calls at block through Multicall3's aggregate3 in batches
+// of maxMulticallBatch. On chains without Multicall3, or at blocks before it
+// was deployed, the calls are made one by one instead.
+func (s *EthService) multicall3(ctx context.Context, calls []call3, block stateBlock) ([]call3Result, error) {
+	results := make([]call3Result, 0, len(calls))
+	for start := 0; start < len(calls); start += maxMulticallBatch {
+		batch := calls[start:min(start+maxMulticallBatch, len(calls))]
+
+		input, err := multicall3ABI.Pack("aggregate3", batch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode aggregate3: %w", err)
+		}
+		output, err := s.callAt(ctx, ethereum.CallMsg{To: &s.multicall, Data: input}, block)
+		if err != nil {
+			return nil, stateError("failed to call Multicall3", err)
+		}
+		if len(output) == 0 {
+			// No contract at the Multicall3 address.
+			return s.callEach(ctx, calls, block)
+		}
+
+		var batchResults []call3Result
+		if err := multicall3ABI.UnpackIntoInterface(&batchResults, "aggregate3", output); err != nil {
+			return nil, upstreamError("failed to decode aggregate3 result", err)
+		}
+		if len(batchResults) != len(batch) {
+			return nil, upstreamError("failed to decode aggregate3 result", fmt.Errorf("got %d results for %d calls", len(batchResults), len(batch)))
+		}
+		results = append(results, batchResults...)
+	}
+	return results, nil
+}
+
+// resultData returns the data a call returned, or nil if it failed.
+func resultData(r call3Result) []byte {
+	if !r.Success {
+		return nil
+	}
+	return r.ReturnData
+}
+
+// callEach makes calls at block one eth_call at a time. Calls that revert
+// fail individually, as with aggregate3.
+func (s *EthService) callEach(ctx context.Context, calls []call3, block stateBlock) ([]call3Result, error) {
+	results := make([]call3Result, len(calls))
+	for i, call := range calls {
+		output, err := s.callAt(ctx, ethereum.CallMsg{To: &call.Target, Data: call.CallData}, block)
+		if err != nil {
+			if isExecutionReverted(err) {
+				continue
+			}
+			return nil, stateError("failed to call contract", err)
+		}
+		results[i] = call3Result{Success: true, ReturnData: output}
+	}
+	return results, nil
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// multicallNode runs aggregate3 calls against in-memory contracts. By
+// default these are ERC-20 tokens with the given balances; contract
+// replaces them.
+type multicallNode struct {
+	ChainReader
+	balances map[common.Address]*big.Int
+	reverts  map[common.Address]bool
+	contract func(target common.Address, data []byte) []byte
+	calls    int
+}
+
+func (n *multicallNode) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	return big.NewInt(5), nil
+}
+
+func (n *multicallNode) ChainID(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+
+// NetworkID differs from the chain ID, as on chains such as Ethereum
+// Classic, so that code confusing the two picks the wrong token list
+// entries.
+func (n *multicallNode) NetworkID(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(5), nil
+}
+
+func (n *multicallNode) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	n.calls++
+	if *msg.To != DefaultMulticall3Address {
+		return nil, errors.New("expected a Multicall3 call")
+	}
+
+	method := multicall3ABI.Methods["aggregate3"]
+	values, err := method.Inputs.Unpack(msg.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	var calls []call3
+	if err := method.Inputs.Copy(&calls, values); err != nil {
+		return nil, err
+	}
+
+	results := make([]call3Result, len(calls))
+	for i, call := range calls {
+		if n.reverts[call.Target] {
+			continue
+		}
+		handle := n.token
+		if n.contract != nil {
+			handle = n.contract
+		}
+		results[i] = call3Result{Success: true, ReturnData: handle(call.Target, call.CallData)}
+	}
+	return method.Outputs.Pack(results)
+}
+
+func (n *multicallNode) token(token common.Address, data []byte) []byte {
+	switch {
+	case bytes.HasPrefix(data, balanceOfSelector):
+		return common.LeftPadBytes(n.balances[token].Bytes(), 32)
+	case bytes.Equal(data, symbolSelector):
+		packed, _ := abi.Arguments{{Type: stringType}}.Pack("TKN")
+		return packed
+	case bytes.Equal(data, decimalsSelector):
+		return common.LeftPadBytes([]byte{18}, 32)
+	}
+	return nil
+}
+
+var (
+	heldToken     = common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	emptyToken    = common.HexToAddress("0x00000000000000000000000000000000000000a2")
+	revertedToken = common.HexToAddress("0x00000000000000000000000000000000000000a3")
+)
+
+func newMulticallNode() *multicallNode {
+	return &multicallNode{
+		balances: map[common.Address]*big.Int{
+			heldToken:  new(big.Int).Mul(big.NewInt(15), big.NewInt(1e17)),
+			emptyToken: new(big.Int),
+		},
+		reverts: map[common.Address]bool{revertedToken: true},
+	}
+}
+
+func TestGetPortfolio(t *testing.T) {
+	node := newMulticallNode()
+	s := NewEthServiceWithClient(node, nil)
+
+	tokens := heldToken.Hex() + "," + emptyToken.Hex() + "," + revertedToken.Hex()
+	portfolio, err := s.GetPortfolio(context.Background(), testTo.Hex(), tokens, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if portfolio.BalanceWei != "5" || len(portfolio.Tokens) != 1 {
+		t.Fatalf("portfolio = %+v", portfolio)
+	}
+	held := portfolio.Tokens[0]
+	if held.TokenAddress != heldToken.Hex() || held.Symbol != "TKN" || held.FormattedBalance != "1.500000000000000000" {
+		t.Errorf("token = %+v", held)
+	}
+	if len(portfolio.FailedTokens) != 1 || portfolio.FailedTokens[0] != revertedToken.Hex() {
+		t.Errorf("failed_tokens = %v", portfolio.FailedTokens)
+	}
+	// One aggregate3 call for the balances and one for the metadata.
+	if node.calls != 2 {
+		t.Errorf("calls = %d, want 2", node.calls)
+	}
+
+	if _, err := s.GetPortfolio(context.Background(), testTo.Hex(), tokens, ""); err != nil {
+		t.Fatal(err)
+	}
+	if node.calls != 3 {
+		t.Errorf("calls = %d, want 3 with cached metadata", node.calls)
+	}
+}
+
+func TestGetPortfolioTokenList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	list := `{"name":"Test","tokens":[
+		{"chainId":1,"address":"` + heldToken.Hex() + `","name":"Held","symbol":"HLD","decimals":6},
+		{"chainId":5,"address":"` + revertedToken.Hex() + `","symbol":"GOR","decimals":18}]}`
+	if err := os.WriteFile(path, []byte(list), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := LoadTokenList(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node := newMulticallNode()
+	s := NewEthServiceWithClient(node, nil)
+	s.SetTokenList(entries)
+
+	portfolio, err := s.GetPortfolio(context.Background(), testTo.Hex(), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(portfolio.Tokens) != 1 || portfolio.Tokens[0].Name != "Held" || portfolio.Tokens[0].FormattedBalance != "1500000000000.000000" {
+		t.Errorf("tokens = %+v", portfolio.Tokens)
+	}
+	// The other chain's token is skipped and metadata comes from the list.
+	if len(portfolio.FailedTokens) != 0 || node.calls != 1 {
+		t.Errorf("failed_tokens = %v, calls = %d", portfolio.FailedTokens, node.calls)
+	}
+}
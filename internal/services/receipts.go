@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// rpcClientProvider is implemented by clients that expose their underlying
+// JSON-RPC connection, such as *ethclient.Client.
+type rpcClientProvider interface {
+	Client() *rpc.Client
+}
+
+// blockReceipts returns the receipts of every transaction in block, in
+// transaction order. It uses eth_getBlockReceipts and falls back to
+// per-transaction receipts on nodes that lack it. Errors are classified.
+func (s *EthService) blockReceipts(ctx context.Context, block *types.Block) ([]*types.Receipt, error) {
+	receipts, err := s.client.BlockReceipts(ctx, rpc.BlockNumberOrHashWithHash(block.Hash(), false))
+	if err == nil {
+		return receipts, nil
+	}
+	if !isMethodNotFound(err) {
+		return nil, upstreamError("failed to fetch block receipts", err)
+	}
+
+	return s.transactionReceipts(ctx, block.Transactions())
+}
+
+// transactionReceipts fetches receipts one per transaction, batched into a
+// single round trip when the client exposes its JSON-RPC connection.
+func (s *EthService) transactionReceipts(ctx context.Context, txs types.Transactions) ([]*types.Receipt, error) {
+	receipts := make([]*types.Receipt, len(txs))
+	if len(txs) == 0 {
+		return receipts, nil
+	}
+
+	client, ok := s.rpcClient()
+	if !ok {
+		for i, tx := range txs {
+			receipt, err := s.client.TransactionReceipt(ctx, tx.Hash())
+			if errors.Is(err, ethereum.NotFound) {
+				return nil, missingReceiptError(tx.Hash())
+			}
+			if err != nil {
+				return nil, upstreamError("failed to fetch receipt of "+tx.Hash().Hex(), err)
+			}
+			receipts[i] = receipt
+		}
+		return receipts, nil
+	}
+
+	batch := make([]rpc.BatchElem, len(txs))
+	for i, tx := range txs {
+		receipts[i] = new(types.Receipt)
+		batch[i] = rpc.BatchElem{
+			Method: "eth_getTransactionReceipt",
+			Args:   []interface{}{tx.Hash()},
+			Result: receipts[i],
+		}
+	}
+
+	if err := client.BatchCallContext(ctx, batch); err != nil {
+		return nil, upstreamError("failed to fetch block receipts", err)
+	}
+	for i, elem := range batch {
+		if elem.Error != nil {
+			return nil, upstreamError("failed to fetch receipt of "+txs[i].Hash().Hex(), elem.Error)
+		}
+		if receipts[i].TxHash != txs[i].Hash() {
+			return nil, missingReceiptError(txs[i].Hash())
+		}
+	}
+
+	return receipts, nil
+}
+
+// missingReceiptError reports a receipt the node does not have for a
+// transaction of a block it returned. The block exists, so it is the node
+// that failed rather than the lookup.
+func missingReceiptError(hash common.Hash) error {
+	return &Error{Kind: ErrUpstreamUnavailable, Msg: "failed to fetch receipt of " + hash.Hex(), Err: ethereum.NotFound}
+}
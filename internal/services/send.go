@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"eth-explorer-api/internal/models"
+	"eth-explorer-api/internal/validation"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// maxSentTransactions is the number of sent transactions whose sender and
+// nonce are remembered.
+const maxSentTransactions = 10000
+
+// sentTransaction is the sender and nonce of a transaction sent through
+// the service. They tell whether a transaction the node no longer knows was
+// replaced or dropped.
+type sentTransaction struct {
+	from  common.Address
+	nonce uint64
+}
+
+// sentTransactions remembers the most recently sent transactions.
+type sentTransactions struct {
+	mu    sync.Mutex
+	txs   map[common.Hash]sentTransaction
+	order []common.Hash
+}
+
+func newSentTransactions() *sentTransactions {
+	return &sentTransactions{txs: make(map[common.Hash]sentTransaction)}
+}
+
+func (t *sentTransactions) add(hash common.Hash, tx sentTransaction) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.txs[hash]; ok {
+		return
+	}
+	t.txs[hash] = tx
+	t.order = append(t.order, hash)
+	if len(t.order) > maxSentTransactions {
+		delete(t.txs, t.order[0])
+		t.order = t.order[1:]
+	}
+}
+
+func (t *sentTransactions) get(hash common.Hash) (sentTransaction, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tx, ok := t.txs[hash]
+	return tx, ok
+}
+
+// rejectTransaction reports a raw transaction that cannot be sent.
+func rejectTransaction(format string, args ...interface{}) error {
+	return invalidInputError("invalid transaction", &validation.FieldError{Field: "raw_transaction", Reason: fmt.Sprintf(format, args...)})
+}
+
+// SendRawTransaction broadcasts a signed transaction, given as 0x-prefixed
+// hex of its binary encoding: RLP for legacy transactions and the typed
+// envelope otherwise. It is checked locally first: it must be replay
+// protected and for the node's chain, and its sender must be able to pay
+// for it at the next free nonce, or at the nonce of a pending transaction
+// it replaces.
+func (s *EthService) SendRawTransaction(ctx context.Context, raw string) (*models.TransactionStatus, error) {
+	if raw == "" {
+		return nil, rejectTransaction("is required")
+	}
+	data, err := hexutil.Decode(raw)
+	if err != nil {
+		return nil, rejectTransaction("must be 0x-prefixed hex")
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(data); err != nil {
+		return nil, rejectTransaction("is not a signed transaction: %v", err)
+	}
+
+	if !tx.Protected() {
+		return nil, rejectTransaction("is not replay-protected (EIP-155)")
+	}
+	chainID, err := s.client.ChainID(ctx)
+	if err != nil {
+		return nil, upstreamError("failed to get chain ID", err)
+	}
+	if tx.ChainId().Cmp(chainID) != 0 {
+		return nil, rejectTransaction("is for chain %s, but the node is on chain %s", tx.ChainId(), chainID)
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(chainID), tx)
+	if err != nil {
+		return nil, rejectTransaction("has an invalid signature: %v", err)
+	}
+
+	nonce, err := s.client.NonceAt(ctx, from, nil)
+	if err != nil {
+		return nil, upstreamError("failed to fetch nonce", err)
+	}
+	pendingNonce, err := s.client.PendingNonceAt(ctx, from)
+	if err != nil {
+		return nil, upstreamError("failed to fetch pending nonce", err)
+	}
+	switch {
+	case tx.Nonce() < nonce:
+		return nil, rejectTransaction("nonce %d was already used; the next nonce of %s is %d", tx.Nonce(), from.Hex(), pendingNonce)
+	case tx.Nonce() > pendingNonce:
+		return nil, rejectTransaction("nonce %d leaves a gap; the next nonce of %s is %d", tx.Nonce(), from.Hex(), pendingNonce)
+	}
+
+	balance, err := s.client.BalanceAt(ctx, from, nil)
+	if err != nil {
+		return nil, upstreamError("failed to fetch balance", err)
+	}
+	if cost := tx.Cost(); balance.Cmp(cost) < 0 {
+		return nil, rejectTransaction("costs up to %s wei, but %s has %s wei", cost, from.Hex(), balance)
+	}
+
+	if err := s.client.SendTransaction(ctx, tx); err != nil && !isAlreadyKnown(err) {
+		if isTransactionRejected(err) {
+			return nil, rejectTransaction("was rejected by the node: %v", err)
+		}
+		return nil, upstreamError("failed to send transaction", err)
+	}
+	s.sent.add(tx.Hash(), sentTransaction{from: from, nonce: tx.Nonce()})
+
+	return &models.TransactionStatus{
+		Hash:   tx.Hash().Hex(),
+		Status: models.TxPending,
+		From:   from.Hex(),
+		Nonce:  tx.Nonce(),
+	}, nil
+}
+
+// isAlreadyKnown reports whether the node rejected a transaction because
+// it already has it.
+func isAlreadyKnown(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "already known") || strings.Contains(msg, "already imported")
+}
+
+// isTransactionRejected reports whether the node refused a transaction, as
+// opposed to failing to process the request. Nodes answer with a JSON-RPC
+// error such as "replacement transaction underpriced" or "nonce too low".
+func isTransactionRejected(err error) bool {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	switch rpcErr.ErrorCode() {
+	case rpcLimitExceededCode, rpcMethodNotFoundCode:
+		return false
+	}
+	return true
+}
+
+// GetTransactionStatus reports where a transaction is in its lifecycle:
+// pending in the mempool, included in a block, or included in a finalized
+// block. Transactions sent through SendRawTransaction that the node no
+// longer knows are reported as replaced when their nonce has been used by
+// another transaction, and as dropped otherwise.
+func (s *EthService) GetTransactionStatus(ctx context.Context, txHash string) (*models.TransactionStatus, error) {
+	hash, err := s.parseHash("hash", txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, isPending, err := s.client.TransactionByHash(ctx, hash)
+	if errors.Is(err, ethereum.NotFound) {
+		return s.forgottenTransactionStatus(ctx, hash, err)
+	}
+	if err != nil {
+		return nil, upstreamError("failed to fetch transaction", err)
+	}
+
+	status := &models.TransactionStatus{Hash: hash.Hex(), Status: models.TxPending, Nonce: tx.Nonce()}
+	if from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx); err == nil {
+		status.From = from.Hex()
+	}
+	if isPending {
+		return status, nil
+	}
+
+	receipt, err := s.client.TransactionReceipt(ctx, hash)
+	if err != nil {
+		return nil, upstreamError("failed to fetch transaction receipt", err)
+	}
+	head, err := s.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, upstreamError("failed to fetch latest block", err)
+	}
+
+	success := receipt.Status == types.ReceiptStatusSuccessful
+	status.Status = models.TxIncluded
+	status.Success = &success
+	status.BlockNumber = receipt.BlockNumber.Uint64()
+	status.BlockHash = receipt.BlockHash.Hex()
+	if head.Number.Cmp(receipt.BlockNumber) >= 0 {
+		status.Confirmations = new(big.Int).Sub(head.Number, receipt.BlockNumber).Uint64() + 1
+	}
+
+	// Chains without a finality gadget have no finalized block.
+	finalized, err := s.client.HeaderByNumber(ctx, big.NewInt(int64(rpc.FinalizedBlockNumber)))
+	if err == nil && finalized.Number.Cmp(receipt.BlockNumber) >= 0 {
+		status.Status = models.TxFinalized
+	}
+	return status, nil
+}
+
+// forgottenTransactionStatus reports the status of a transaction the node
+// does not know, which is only known for transactions sent through the
+// service.
+func (s *EthService) forgottenTransactionStatus(ctx context.Context, hash common.Hash, notFound error) (*models.TransactionStatus, error) {
+	sent, ok := s.sent.get(hash)
+	if !ok {
+		return nil, notFoundError("transaction not found", notFound)
+	}
+
+	// The pending nonce moves past the transaction's once another
+	// transaction with its nonce is pending or included.
+	pendingNonce, err := s.client.PendingNonceAt(ctx, sent.from)
+	if err != nil {
+		return nil, upstreamError("failed to fetch pending nonce", err)
+	}
+	status := &models.TransactionStatus{Hash: hash.Hex(), Status: models.TxDropped, From: sent.from.Hex(), Nonce: sent.nonce}
+	if pendingNonce > sent.nonce {
+		status.Status = models.TxReplaced
+	}
+	return status, nil
+}
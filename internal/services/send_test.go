@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"eth-explorer-api/internal/models"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// poolNode has forgotten every transaction. pendingNonce is the next nonce
+// of every account, counting transactions in the mempool.
+type poolNode struct {
+	ChainReader
+	pendingNonce uint64
+}
+
+func (n *poolNode) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	return nil, false, ethereum.NotFound
+}
+
+func (n *poolNode) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return n.pendingNonce, nil
+}
+
+func TestForgottenTransactionStatus(t *testing.T) {
+	ctx := context.Background()
+	node := &poolNode{pendingNonce: 4}
+	s := NewEthServiceWithClient(node, nil)
+
+	sentHash := common.HexToHash("0x01")
+	s.sent.add(sentHash, sentTransaction{from: testTo, nonce: 4})
+
+	// Nothing else took nonce 4.
+	status, err := s.GetTransactionStatus(ctx, sentHash.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != models.TxDropped || status.From != testTo.Hex() || status.Nonce != 4 {
+		t.Errorf("status = %+v, want dropped", status)
+	}
+
+	node.pendingNonce = 5
+	if status, err = s.GetTransactionStatus(ctx, sentHash.Hex()); err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != models.TxReplaced {
+		t.Errorf("status = %s, want replaced", status.Status)
+	}
+
+	// Transactions sent elsewhere cannot be told apart.
+	if _, err := s.GetTransactionStatus(ctx, common.HexToHash("0x02").Hex()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("error = %v, want not found", err)
+	}
+}
+
+func TestSentTransactionsEviction(t *testing.T) {
+	sent := newSentTransactions()
+	for i := 0; i <= maxSentTransactions; i++ {
+		sent.add(common.BigToHash(big.NewInt(int64(i))), sentTransaction{nonce: uint64(i)})
+	}
+	if _, ok := sent.get(common.Hash{}); ok {
+		t.Error("the oldest transaction was not evicted")
+	}
+	if tx, ok := sent.get(common.BigToHash(big.NewInt(maxSentTransactions))); !ok || tx.nonce != maxSentTransactions {
+		t.Errorf("newest transaction = %+v, %t", tx, ok)
+	}
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"eth-explorer-api/internal/decoder"
+	"eth-explorer-api/internal/models"
+	"eth-explorer-api/internal/validation"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// simulatedCall is the outcome of running a transaction without sending it.
+// A call that failed either reverted, with the revert data in returnData,
+// or was rejected for another reason given in failure.
+type simulatedCall struct {
+	returnData []byte
+	gasUsed    uint64
+	reverted   bool
+	failure    string
+
+	// logs is nil when the node cannot report the logs of a call.
+	logs []types.Log
+}
+
+func (c *simulatedCall) success() bool {
+	return !c.reverted && c.failure == ""
+}
+
+// Simulate runs a transaction against the state at a block, with optional
+// state overrides, without sending it. It reports whether the transaction
+// would succeed, its return data and, on success, its gas estimate. The
+// logs it emits are reported when the node implements eth_simulateV1.
+// Reverts and transactions the node rejects, e.g. for insufficient funds,
+// are not errors.
+func (s *EthService) Simulate(ctx context.Context, req *models.SimulateRequest) (*models.Simulation, error) {
+	msg, err := s.parseSimulateRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	block, err := s.parseStateBlock("block", req.Block)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := s.parseStateOverrides("state_overrides", req.StateOverrides)
+	if err != nil {
+		return nil, err
+	}
+
+	call, err := s.simulateCall(ctx, msg, block, overrides)
+	if err != nil {
+		return nil, err
+	}
+	if !call.success() {
+		return s.simulation(ctx, msg, call), nil
+	}
+
+	gas, err := s.estimateGas(ctx, msg, block, overrides)
+	if err != nil {
+		// The estimate runs the transaction again with varying gas limits,
+		// which can fail where the call did not.
+		if data, ok := revertData(err); ok {
+			call.returnData, call.reverted = data, true
+		} else if call.failure = transactionFailure(err); call.failure == "" {
+			return nil, stateError("failed to estimate gas", err)
+		}
+		call.logs = nil
+		return s.simulation(ctx, msg, call), nil
+	}
+
+	sim := s.simulation(ctx, msg, call)
+	sim.GasEstimate = gas
+	return sim, nil
+}
+
+// parseSimulateRequest parses the transaction of a simulation request. A
+// transaction without a recipient deploys a contract.
+func (s *EthService) parseSimulateRequest(req *models.SimulateRequest) (ethereum.CallMsg, error) {
+	var msg ethereum.CallMsg
+	var err error
+	if req.From != "" {
+		if msg.From, err = s.parseAddress("from", req.From); err != nil {
+			return msg, err
+		}
+	}
+	if req.To != "" {
+		to, err := s.parseAddress("to", req.To)
+		if err != nil {
+			return msg, err
+		}
+		msg.To = &to
+	}
+	if req.Value != "" {
+		if msg.Value, err = validation.Uint256("value", req.Value); err != nil {
+			return msg, invalidInputError("invalid request", err)
+		}
+	}
+	if req.Data != "" {
+		if msg.Data, err = hexutil.Decode(req.Data); err != nil {
+			return msg, invalidInputError("invalid request", &validation.FieldError{Field: "data", Value: req.Data, Reason: "must be 0x-prefixed hex"})
+		}
+	}
+	if req.Gas != "" {
+		if msg.Gas, err = strconv.ParseUint(req.Gas, 0, 64); err != nil || msg.Gas == 0 {
+			return msg, invalidInputError("invalid request", &validation.FieldError{Field: "gas", Value: req.Gas, Reason: "must be a positive integer"})
+		}
+	}
+	if msg.To == nil && len(msg.Data) == 0 {
+		return msg, invalidInputError("invalid request", &validation.FieldError{Field: "to", Reason: "is required unless data holds contract creation code"})
+	}
+	return msg, nil
+}
+
+// simulation builds the response for a simulated call, decoding its revert
+// reason and logs against the ABIs of the contracts involved.
+func (s *EthService) simulation(ctx context.Context, msg ethereum.CallMsg, call *simulatedCall) *models.Simulation {
+	sim := &models.Simulation{
+		Success:    call.success(),
+		GasUsed:    call.gasUsed,
+		ReturnData: hexutil.Encode(call.returnData),
+		Error:      call.failure,
+	}
+
+	type resolvedABI struct {
+		abi    *abi.ABI
+		source string
+	}
+	abis := make(map[common.Address]resolvedABI)
+	resolve := func(address common.Address) resolvedABI {
+		resolved, ok := abis[address]
+		if !ok {
+			resolved.abi, resolved.source = s.contractABI(ctx, address)
+			abis[address] = resolved
+		}
+		return resolved
+	}
+
+	if call.reverted {
+		var contractABI *abi.ABI
+		if msg.To != nil {
+			contractABI = resolve(*msg.To).abi
+		}
+		sim.Revert = decoder.DecodeRevert(call.returnData, contractABI)
+	}
+	if call.logs != nil {
+		sim.Logs = []models.EventLog{}
+		for _, vLog := range call.logs {
+			resolved := resolve(vLog.Address)
+			eventLog := logToModel(&vLog)
+			eventLog.Decoded = decodeLog(resolved.abi, resolved.source, &vLog)
+			sim.Logs = append(sim.Logs, eventLog)
+		}
+	}
+	return sim
+}
+
+// simCallResult is a call result of eth_simulateV1.
+type simCallResult struct {
+	ReturnData hexutil.Bytes  `json:"returnData"`
+	Logs       []types.Log    `json:"logs"`
+	GasUsed    hexutil.Uint64 `json:"gasUsed"`
+	Status     hexutil.Uint64 `json:"status"`
+	Error      *struct {
+		Message string `json:"message"`
+		Code    int    `json:"code"`
+		Data    string `json:"data"`
+	} `json:"error"`
+}
+
+// simulateCall runs msg at block with eth_simulateV1, which reports the
+// logs the call emits, and falls back to eth_call on nodes without it.
+func (s *EthService) simulateCall(ctx context.Context, msg ethereum.CallMsg, block stateBlock, overrides stateOverrides) (*simulatedCall, error) {
+	client, ok := s.rpcClient()
+	if ok {
+		simBlock := map[string]interface{}{"calls": []interface{}{callArg(msg)}}
+		if len(overrides) > 0 {
+			simBlock["stateOverrides"] = overrides
+		}
+		opts := map[string]interface{}{"blockStateCalls": []interface{}{simBlock}}
+
+		var blocks []struct {
+			Calls []simCallResult `json:"calls"`
+		}
+		err := client.CallContext(ctx, &blocks, "eth_simulateV1", opts, block.rpcArg())
+		switch {
+		case err == nil && len(blocks) == 1 && len(blocks[0].Calls) == 1:
+			return simulatedCallResult(blocks[0].Calls[0]), nil
+		case err == nil:
+			return nil, upstreamError("failed to simulate transaction", fmt.Errorf("got %d blocks of results for one call", len(blocks)))
+		case !isMethodNotFound(err):
+			return simulatedCallError(err)
+		}
+	}
+
+	output, err := s.callWithOverrides(ctx, msg, block, overrides)
+	if err != nil {
+		return simulatedCallError(err)
+	}
+	return &simulatedCall{returnData: output}, nil
+}
+
+// simulatedCallResult converts a call result of eth_simulateV1.
+func simulatedCallResult(result simCallResult) *simulatedCall {
+	call := &simulatedCall{returnData: result.ReturnData, gasUsed: uint64(result.GasUsed), logs: result.Logs}
+	if call.logs == nil {
+		call.logs = []types.Log{}
+	}
+	if uint64(result.Status) == types.ReceiptStatusSuccessful {
+		return call
+	}
+
+	call.logs = []types.Log{}
+	switch {
+	case result.Error == nil:
+		call.reverted = true
+	case result.Error.Data != "":
+		call.reverted = true
+		if data, err := hexutil.Decode(result.Error.Data); err == nil {
+			call.returnData = data
+		}
+	case result.Error.Code == rpcVMErrorCode:
+		call.failure = result.Error.Message
+	default:
+		call.reverted = true
+	}
+	return call
+}
+
+// simulatedCallError turns the error of a call that did not run to
+// completion into the outcome of the simulation, unless the node failed.
+func simulatedCallError(err error) (*simulatedCall, error) {
+	if data, ok := revertData(err); ok {
+		return &simulatedCall{returnData: data, reverted: true}, nil
+	}
+	if failure := transactionFailure(err); failure != "" {
+		return &simulatedCall{failure: failure}, nil
+	}
+	return nil, stateError("failed to simulate transaction", err)
+}
+
+// estimateGas estimates the gas msg needs at block, through a raw
+// eth_estimateGas call when the block is not the latest or there are state
+// overrides.
+func (s *EthService) estimateGas(ctx context.Context, msg ethereum.CallMsg, block stateBlock, overrides stateOverrides) (uint64, error) {
+	if len(overrides) == 0 && block == (stateBlock{}) {
+		return s.client.EstimateGas(ctx, msg)
+	}
+
+	client, ok := s.rpcClient()
+	if !ok {
+		return 0, unsupportedError("estimating gas at a past block or with state overrides requires a JSON-RPC connection to the node", nil)
+	}
+	args := []interface{}{callArg(msg), block.rpcArg()}
+	if len(overrides) > 0 {
+		args = append(args, overrides)
+	}
+	var gas hexutil.Uint64
+	if err := client.CallContext(ctx, &gas, "eth_estimateGas", args...); err != nil {
+		return 0, err
+	}
+	return uint64(gas), nil
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"eth-explorer-api/internal/models"
+
+	"github.com/ethereum/go-ethereum"
+)
+
+// nodeError is a JSON-RPC error response, with revert data when data is
+// set.
+type nodeError struct {
+	code int
+	msg  string
+	data string
+}
+
+func (e nodeError) Error() string  { return e.msg }
+func (e nodeError) ErrorCode() int { return e.code }
+func (e nodeError) ErrorData() interface{} {
+	if e.data == "" {
+		return nil
+	}
+	return e.data
+}
+
+// callNode answers eth_call and eth_estimateGas without eth_simulateV1,
+// failing both with err when set.
+type callNode struct {
+	ChainReader
+	err error
+}
+
+func (n *callNode) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if n.err != nil {
+		return nil, n.err
+	}
+	return []byte{0x01}, nil
+}
+
+func (n *callNode) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	if n.err != nil {
+		return 0, n.err
+	}
+	return 21000, nil
+}
+
+func TestSimulate(t *testing.T) {
+	ctx := context.Background()
+	node := &callNode{}
+	s := NewEthServiceWithClient(node, nil)
+	req := &models.SimulateRequest{To: testTo.Hex(), Data: "0x12345678"}
+
+	sim, err := s.Simulate(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Without eth_simulateV1 there are no logs to report.
+	if !sim.Success || sim.GasEstimate != 21000 || sim.ReturnData != "0x01" || sim.Logs != nil {
+		t.Errorf("simulation = %+v", sim)
+	}
+
+	// Error(string) "nope"
+	reason := "0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000004" +
+		"6e6f706500000000000000000000000000000000000000000000000000000000"
+	node.err = nodeError{code: 3, msg: "execution reverted: nope", data: reason}
+	if sim, err = s.Simulate(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if sim.Success || sim.Revert == nil || sim.Revert.Reason != "nope" || sim.ReturnData != reason {
+		t.Errorf("simulation = %+v, revert = %+v", sim, sim.Revert)
+	}
+
+	node.err = nodeError{code: -32000, msg: "insufficient funds for gas * price + value"}
+	if sim, err = s.Simulate(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if sim.Success || sim.Revert != nil || sim.Error != node.err.Error() {
+		t.Errorf("simulation = %+v", sim)
+	}
+
+	node.err = nodeError{code: -32000, msg: "missing trie node abc"}
+	if _, err := s.Simulate(ctx, req); !errors.Is(err, ErrStateUnavailable) {
+		t.Errorf("error = %v, want state unavailable", err)
+	}
+
+	if _, err := s.Simulate(ctx, &models.SimulateRequest{To: testTo.Hex(), Gas: "lots"}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("error = %v, want invalid input", err)
+	}
+	if _, err := s.Simulate(ctx, &models.SimulateRequest{Data: "0x"}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("error = %v, want invalid input without a recipient or code", err)
+	}
+}
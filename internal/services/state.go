@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"eth-explorer-api/internal/models"
+	"eth-explorer-api/internal/validation"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// stateBlock is the block whose state a balance lookup or contract call
+// reads. The zero value is the latest block.
+type stateBlock struct {
+	number *big.Int
+	hash   *common.Hash
+}
+
+// parseStateBlock parses a block number, tag or 0x-prefixed 32-byte block
+// hash. An empty value means "latest".
+func (s *EthService) parseStateBlock(field, value string) (stateBlock, error) {
+	if value == "" {
+		return stateBlock{}, nil
+	}
+	if len(value) == 2+2*common.HashLength && strings.HasPrefix(strings.ToLower(value), "0x") {
+		hash, err := s.parseHash(field, value)
+		if err != nil {
+			return stateBlock{}, err
+		}
+		return stateBlock{hash: &hash}, nil
+	}
+
+	num, tag, err := s.parseBlockID(field, value)
+	if err != nil {
+		return stateBlock{}, err
+	}
+	if tag == "latest" {
+		return stateBlock{}, nil
+	}
+	return stateBlock{number: num}, nil
+}
+
+// rpcArg returns the block as a JSON-RPC block parameter.
+func (b stateBlock) rpcArg() rpc.BlockNumberOrHash {
+	switch {
+	case b.hash != nil:
+		return rpc.BlockNumberOrHashWithHash(*b.hash, false)
+	case b.number != nil:
+		return rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(b.number.Int64()))
+	}
+	return rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+}
+
+// balanceAt returns the balance of account at block.
+func (s *EthService) balanceAt(ctx context.Context, account common.Address, block stateBlock) (*big.Int, error) {
+	var balance *big.Int
+	var err error
+	if block.hash != nil {
+		balance, err = s.client.BalanceAtHash(ctx, account, *block.hash)
+	} else {
+		balance, err = s.client.BalanceAt(ctx, account, block.number)
+	}
+	if err != nil {
+		return nil, stateError("failed to fetch balance", err)
+	}
+	return balance, nil
+}
+
+// callAt runs msg against the state at block. Errors are returned as
+// reported by the node, so callers can tell reverts apart; pass them
+// through stateError.
+func (s *EthService) callAt(ctx context.Context, msg ethereum.CallMsg, block stateBlock) ([]byte, error) {
+	if block.hash != nil {
+		return s.client.CallContractAtHash(ctx, msg, *block.hash)
+	}
+	return s.client.CallContract(ctx, msg, block.number)
+}
+
+// stateOverrides are per-account state replacements for eth_call.
+type stateOverrides map[common.Address]gethclient.OverrideAccount
+
+// parseStateOverrides validates the state overrides of a request. Balances,
+// nonces and storage slots and values are integers in decimal or
+// 0x-prefixed hex.
+func (s *EthService) parseStateOverrides(field string, overrides map[string]models.AccountOverride) (stateOverrides, error) {
+	if len(overrides) == 0 {
+		return nil, nil
+	}
+
+	parsed := make(stateOverrides, len(overrides))
+	for address, override := range overrides {
+		account, err := s.parseAddress(field, address)
+		if err != nil {
+			return nil, err
+		}
+		prefix := field + "." + address + "."
+
+		var result gethclient.OverrideAccount
+		if override.Balance != "" {
+			if result.Balance, err = validation.Uint256(prefix+"balance", override.Balance); err != nil {
+				return nil, invalidInputError("invalid request", err)
+			}
+		}
+		if override.Nonce != "" {
+			nonce, err := validation.Uint256(prefix+"nonce", override.Nonce)
+			if err == nil && !nonce.IsUint64() {
+				err = &validation.FieldError{Field: prefix + "nonce", Value: override.Nonce, Reason: "does not fit in 64 bits"}
+			}
+			if err != nil {
+				return nil, invalidInputError("invalid request", err)
+			}
+			result.Nonce = nonce.Uint64()
+		}
+		if override.Code != "" {
+			if result.Code, err = hexutil.Decode(override.Code); err != nil {
+				return nil, invalidInputError("invalid request", &validation.FieldError{Field: prefix + "code", Value: override.Code, Reason: "is not 0x-prefixed hex"})
+			}
+		}
+		if result.State, err = parseStorage(prefix+"state", override.State); err != nil {
+			return nil, err
+		}
+		if result.StateDiff, err = parseStorage(prefix+"state_diff", override.StateDiff); err != nil {
+			return nil, err
+		}
+
+		parsed[account] = result
+	}
+	return parsed, nil
+}
+
+// parseStorage parses a map of storage slots to values.
+func parseStorage(field string, storage map[string]string) (map[common.Hash]common.Hash, error) {
+	if storage == nil {
+		return nil, nil
+	}
+
+	parsed := make(map[common.Hash]common.Hash, len(storage))
+	for slot, value := range storage {
+		key, err := validation.Uint256(field, slot)
+		if err != nil {
+			return nil, invalidInputError("invalid request", err)
+		}
+		word, err := validation.Uint256(field, value)
+		if err != nil {
+			return nil, invalidInputError("invalid request", err)
+		}
+		parsed[common.BigToHash(key)] = common.BigToHash(word)
+	}
+	return parsed, nil
+}
+
+// rpcClient returns the JSON-RPC client behind the ChainReader, for calls
+// ethclient does not wrap. *ethclient.Client provides one.
+func (s *EthService) rpcClient() (*rpc.Client, bool) {
+	provider, ok := s.client.(rpcClientProvider)
+	if !ok {
+		return nil, false
+	}
+	return provider.Client(), true
+}
+
+// callWithOverrides runs msg at block like callAt, applying state
+// overrides through a raw eth_call when there are any.
+func (s *EthService) callWithOverrides(ctx context.Context, msg ethereum.CallMsg, block stateBlock, overrides stateOverrides) ([]byte, error) {
+	if len(overrides) == 0 {
+		return s.callAt(ctx, msg, block)
+	}
+
+	client, ok := s.rpcClient()
+	if !ok {
+		return nil, unsupportedError("state overrides require a JSON-RPC connection to the node", nil)
+	}
+	var result hexutil.Bytes
+	if err := client.CallContext(ctx, &result, "eth_call", callArg(msg), block.rpcArg(), overrides); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// callArg encodes msg as a JSON-RPC transaction call object, as ethclient
+// does.
+func callArg(msg ethereum.CallMsg) map[string]interface{} {
+	arg := map[string]interface{}{
+		"from": msg.From,
+		"to":   msg.To,
+	}
+	if len(msg.Data) > 0 {
+		arg["input"] = hexutil.Bytes(msg.Data)
+	}
+	if msg.Value != nil {
+		arg["value"] = (*hexutil.Big)(msg.Value)
+	}
+	if msg.Gas != 0 {
+		arg["gas"] = hexutil.Uint64(msg.Gas)
+	}
+	return arg
+}
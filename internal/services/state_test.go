@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// prunedNode is a full node that only has the state of block head.
+type prunedNode struct {
+	ChainReader
+	head       uint64
+	hashCalls  int
+	headerHash common.Hash
+}
+
+func (n *prunedNode) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	switch {
+	case blockNumber == nil || blockNumber.Uint64() == n.head:
+		return big.NewInt(7), nil
+	case blockNumber.Uint64() > n.head:
+		return nil, errors.New("header not found")
+	}
+	return nil, errors.New("missing trie node 9ad4c0e2b3e8a2d6f5fd86bd7a6ae7fa4e7e9a4c0f2b5c0e7a9e6a3ad7f0c8e1 (path )")
+}
+
+func (n *prunedNode) BalanceAtHash(ctx context.Context, account common.Address, blockHash common.Hash) (*big.Int, error) {
+	n.hashCalls++
+	if blockHash != n.headerHash {
+		return nil, errors.New("header for hash not found")
+	}
+	return big.NewInt(7), nil
+}
+
+func (n *prunedNode) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return nil, errors.New("required historical state unavailable (reexec=128)")
+}
+
+func TestGetBalanceAtBlock(t *testing.T) {
+	node := &prunedNode{head: 100, headerHash: common.HexToHash("0x01")}
+	s := NewEthServiceWithClient(node, nil)
+	ctx := context.Background()
+
+	for _, block := range []string{"", "latest", "100", "0x64", node.headerHash.Hex()} {
+		balance, err := s.GetBalance(ctx, testTo.Hex(), block)
+		if err != nil {
+			t.Fatalf("block %q: %v", block, err)
+		}
+		if balance.BalanceWei != "7" {
+			t.Errorf("block %q: balance_wei = %s, want 7", block, balance.BalanceWei)
+		}
+	}
+	if node.hashCalls != 1 {
+		t.Errorf("hash lookups = %d, want 1", node.hashCalls)
+	}
+
+	tests := []struct {
+		block string
+		want  error
+	}{
+		{"50", ErrStateUnavailable},
+		{"101", ErrNotFound},
+		{common.HexToHash("0x02").Hex(), ErrNotFound},
+		{"0x01", ErrStateUnavailable},
+		{"tomorrow", ErrInvalidInput},
+	}
+	for _, tt := range tests {
+		if _, err := s.GetBalance(ctx, testTo.Hex(), tt.block); !errors.Is(err, tt.want) {
+			t.Errorf("block %q: error = %v, want %v", tt.block, err, tt.want)
+		}
+	}
+
+	_, err := s.GetTokenBalance(ctx, testTo.Hex(), heldToken.Hex(), "50")
+	if !errors.Is(err, ErrStateUnavailable) {
+		t.Errorf("token balance error = %v, want %v", err, ErrStateUnavailable)
+	}
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"eth-explorer-api/internal/models"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ERC-20 function selectors.
+var (
+	nameSelector        = []byte{0x06, 0xfd, 0xde, 0x03}
+	symbolSelector      = []byte{0x95, 0xd8, 0x9b, 0x41}
+	decimalsSelector    = []byte{0x31, 0x3c, 0xe5, 0x67}
+	totalSupplySelector = []byte{0x18, 0x16, 0x0d, 0xdd}
+	balanceOfSelector   = []byte{0x70, 0xa0, 0x82, 0x31}
+)
+
+var stringType, _ = abi.NewType("string", "", nil)
+
+// tokenInfo is the immutable part of a token's metadata. Decimals is nil
+// when the token does not implement decimals().
+type tokenInfo struct {
+	name     string
+	symbol   string
+	decimals *uint8
+}
+
+// tokenCache caches tokenInfo by token address. Name, symbol and decimals
+// never change after deployment, so entries do not expire.
+type tokenCache struct {
+	mu     sync.Mutex
+	tokens map[common.Address]tokenInfo
+}
+
+func newTokenCache() *tokenCache {
+	return &tokenCache{tokens: make(map[common.Address]tokenInfo)}
+}
+
+func (c *tokenCache) get(token common.Address) (tokenInfo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	info, ok := c.tokens[token]
+	return info, ok
+}
+
+// add caches info. Empty infos, such as those of an address with no
+// contract yet, are not cached so the token is looked up again later.
+func (c *tokenCache) add(token common.Address, info tokenInfo) {
+	if info == (tokenInfo{}) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tokens[token] = info
+}
+
+// GetTokenMetadata retrieves the name, symbol, decimals and total supply of
+// an ERC-20 token. Optional functions the token does not implement are
+// omitted. The total supply is read at block, or at the latest block when
+// block is empty.
+func (s *EthService) GetTokenMetadata(ctx context.Context, tokenAddress, block string) (*models.TokenMetadata, error) {
+	token, err := s.parseAddress("address", tokenAddress)
+	if err != nil {
+		return nil, err
+	}
+	at, err := s.parseStateBlock("block", block)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := s.tokenInfo(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.callToken(ctx, token, totalSupplySelector, at)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) < 32 && info == (tokenInfo{}) {
+		return nil, notFoundError("token not found", nil)
+	}
+
+	metadata := &models.TokenMetadata{
+		Address:  token.Hex(),
+		Name:     info.name,
+		Symbol:   info.symbol,
+		Decimals: info.decimals,
+	}
+	if len(result) >= 32 {
+		totalSupply := new(big.Int).SetBytes(result[:32])
+		metadata.TotalSupply = totalSupply.String()
+		metadata.FormattedTotalSupply = info.format(totalSupply)
+	}
+
+	return metadata, nil
+}
+
+// tokenInfo returns the cached name, symbol and decimals of token, reading
+// them from the latest block until one of them is found.
+func (s *EthService) tokenInfo(ctx context.Context, token common.Address) (tokenInfo, error) {
+	if info, ok := s.tokens.get(token); ok {
+		return info, nil
+	}
+
+	name, err := s.callToken(ctx, token, nameSelector, stateBlock{})
+	if err != nil {
+		return tokenInfo{}, err
+	}
+	symbol, err := s.callToken(ctx, token, symbolSelector, stateBlock{})
+	if err != nil {
+		return tokenInfo{}, err
+	}
+	decimals, err := s.callToken(ctx, token, decimalsSelector, stateBlock{})
+	if err != nil {
+		return tokenInfo{}, err
+	}
+
+	info := newTokenInfo(name, symbol, decimals)
+	s.tokens.add(token, info)
+	return info, nil
+}
+
+// newTokenInfo decodes the results of name(), symbol() and decimals(). A
+// nil result stands for a call that reverted.
+func newTokenInfo(name, symbol, decimals []byte) tokenInfo {
+	info := tokenInfo{
+		name:   decodeTokenString(name),
+		symbol: decodeTokenString(symbol),
+	}
+	if len(decimals) >= 32 {
+		if n := new(big.Int).SetBytes(decimals[:32]); n.IsUint64() && n.Uint64() <= 255 {
+			d := uint8(n.Uint64())
+			info.decimals = &d
+		}
+	}
+	return info
+}
+
+// callToken calls a parameterless function of token at block. A call that
+// reverts returns no data rather than an error, as optional ERC-20
+// functions may be missing.
+func (s *EthService) callToken(ctx context.Context, token common.Address, data []byte, block stateBlock) ([]byte, error) {
+	result, err := s.callAt(ctx, ethereum.CallMsg{To: &token, Data: data}, block)
+	if err != nil {
+		if isExecutionReverted(err) {
+			return nil, nil
+		}
+		return nil, stateError("failed to call token contract", err)
+	}
+	return result, nil
+}
+
+// decodeTokenString decodes the result of name() or symbol(). Tokens
+// predating the ERC-20 standard, such as MKR, return bytes32 instead of
+// string. It returns "" for results that are neither.
+func decodeTokenString(result []byte) string {
+	if values, err := (abi.Arguments{{Type: stringType}}).Unpack(result); err == nil {
+		return strings.TrimSpace(values[0].(string))
+	}
+
+	if len(result) != 32 {
+		return ""
+	}
+	text := bytes.TrimRight(result, "\x00")
+	if len(text) == 0 || !utf8.Valid(text) {
+		return ""
+	}
+	for _, r := range string(text) {
+		if !unicode.IsPrint(r) {
+			return ""
+		}
+	}
+	return string(text)
+}
+
+// format renders amount in whole token units, or "" when the token's
+// decimals are unknown.
+func (t tokenInfo) format(amount *big.Int) string {
+	if t.decimals == nil {
+		return ""
+	}
+	return formatUnits(amount, *t.decimals)
+}
+
+// formatUnits renders amount divided by 10^decimals exactly, with all
+// decimal places, e.g. 1500000 with 6 decimals is "1.500000".
+func formatUnits(amount *big.Int, decimals uint8) string {
+	digits := new(big.Int).Abs(amount).String()
+	if decimals > 0 {
+		if pad := int(decimals) + 1 - len(digits); pad > 0 {
+			digits = strings.Repeat("0", pad) + digits
+		}
+		point := len(digits) - int(decimals)
+		digits = digits[:point] + "." + digits[point:]
+	}
+	if amount.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
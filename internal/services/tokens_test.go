@@ -0,0 +1,135 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestFormatUnits(t *testing.T) {
+	tests := []struct {
+		amount   int64
+		decimals uint8
+		want     string
+	}{
+		{1500000, 6, "1.500000"},
+		{1, 6, "0.000001"},
+		{0, 2, "0.00"},
+		{42, 0, "42"},
+		{-250, 2, "-2.50"},
+	}
+
+	for _, tt := range tests {
+		if got := formatUnits(big.NewInt(tt.amount), tt.decimals); got != tt.want {
+			t.Errorf("formatUnits(%d, %d) = %s, want %s", tt.amount, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeTokenString(t *testing.T) {
+	packed, err := abi.Arguments{{Type: stringType}}.Pack("Wrapped Ether")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mkr := common.RightPadBytes([]byte("MKR"), 32)
+
+	tests := []struct {
+		name   string
+		result []byte
+		want   string
+	}{
+		{"string", packed, "Wrapped Ether"},
+		{"bytes32", mkr, "MKR"},
+		{"number", common.LeftPadBytes([]byte{0x03, 0xe8}, 32), ""},
+		{"empty", nil, ""},
+	}
+
+	for _, tt := range tests {
+		if got := decodeTokenString(tt.result); got != tt.want {
+			t.Errorf("%s: decodeTokenString = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// tokenNode serves ERC-20 calls once the token is deployed. Metadata calls
+// fail with metadataErr when it is set.
+type tokenNode struct {
+	ChainReader
+	deployed    bool
+	metadataErr error
+	calls       int
+}
+
+func (n *tokenNode) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	n.calls++
+	if !n.deployed {
+		return nil, nil
+	}
+	if bytes.HasPrefix(msg.Data, balanceOfSelector) {
+		return common.LeftPadBytes(big.NewInt(1500000).Bytes(), 32), nil
+	}
+	if n.metadataErr != nil {
+		return nil, n.metadataErr
+	}
+	if bytes.Equal(msg.Data, decimalsSelector) {
+		return common.LeftPadBytes([]byte{6}, 32), nil
+	}
+	return nil, nil
+}
+
+func TestTokenInfoCache(t *testing.T) {
+	node := &tokenNode{}
+	s := NewEthServiceWithClient(node, nil)
+	ctx := context.Background()
+
+	info, err := s.tokenInfo(ctx, heldToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info != (tokenInfo{}) {
+		t.Fatalf("info before deployment = %+v, want empty", info)
+	}
+
+	node.deployed = true
+	if info, err = s.tokenInfo(ctx, heldToken); err != nil {
+		t.Fatal(err)
+	}
+	if info.decimals == nil || *info.decimals != 6 {
+		t.Fatalf("info after deployment = %+v, want 6 decimals", info)
+	}
+
+	calls := node.calls
+	if _, err := s.tokenInfo(ctx, heldToken); err != nil {
+		t.Fatal(err)
+	}
+	if node.calls != calls {
+		t.Errorf("calls = %d, want %d with cached metadata", node.calls, calls)
+	}
+}
+
+func TestGetTokenBalanceWithoutMetadata(t *testing.T) {
+	node := &tokenNode{deployed: true, metadataErr: errors.New("connection reset")}
+	s := NewEthServiceWithClient(node, nil)
+
+	balance, err := s.GetTokenBalance(context.Background(), testTo.Hex(), heldToken.Hex(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Balance != "1500000" || balance.FormattedBalance != "" || balance.Decimals != nil {
+		t.Errorf("balance = %+v, want the raw balance only", balance)
+	}
+
+	node.metadataErr = nil
+	if balance, err = s.GetTokenBalance(context.Background(), testTo.Hex(), heldToken.Hex(), ""); err != nil {
+		t.Fatal(err)
+	}
+	if balance.FormattedBalance != "1.500000" {
+		t.Errorf("formatted_balance = %s, want 1.500000", balance.FormattedBalance)
+	}
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"eth-explorer-api/internal/decoder"
+	"eth-explorer-api/internal/models"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// callFrame is a call of Geth's callTracer output.
+type callFrame struct {
+	Type    string          `json:"type"`
+	From    common.Address  `json:"from"`
+	To      *common.Address `json:"to"`
+	Value   *hexutil.Big    `json:"value"`
+	Gas     hexutil.Uint64  `json:"gas"`
+	GasUsed hexutil.Uint64  `json:"gasUsed"`
+	Input   hexutil.Bytes   `json:"input"`
+	Output  hexutil.Bytes   `json:"output"`
+	Error   string          `json:"error"`
+	Calls   []callFrame     `json:"calls"`
+}
+
+// TraceTransaction returns the call tree of a mined transaction, from
+// debug_traceTransaction with the callTracer, and the ETH transfers made by
+// the contracts it called. The node must expose the debug namespace; nodes
+// that do not get an ErrUnsupported error.
+func (s *EthService) TraceTransaction(ctx context.Context, txHash string) (*models.TransactionTrace, error) {
+	hash, err := s.parseHash("hash", txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	client, ok := s.rpcClient()
+	if !ok {
+		return nil, unsupportedError("tracing requires a JSON-RPC connection to the node", nil)
+	}
+	var root callFrame
+	if err := client.CallContext(ctx, &root, "debug_traceTransaction", hash, map[string]interface{}{"tracer": "callTracer"}); err != nil {
+		switch {
+		case isMethodNotFound(err):
+			return nil, unsupportedError("the node does not expose debug_traceTransaction; tracing requires a node with the debug namespace enabled", err)
+		case isTransactionNotFound(err):
+			return nil, notFoundError("transaction not found", err)
+		}
+		return nil, stateError("failed to trace transaction", err)
+	}
+
+	trace := &models.TransactionTrace{
+		Hash:              hash.Hex(),
+		Call:              callFrameToModel(&root),
+		InternalTransfers: []models.InternalTransfer{},
+	}
+	collectTransfers(&root, 0, false, &trace.InternalTransfers)
+	return trace, nil
+}
+
+// isTransactionNotFound reports whether the node could not trace a
+// transaction because it does not know it.
+func isTransactionNotFound(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "transaction") && strings.Contains(msg, "not found")
+}
+
+func callFrameToModel(frame *callFrame) models.CallFrame {
+	call := models.CallFrame{
+		Type:    frame.Type,
+		From:    frame.From.Hex(),
+		Gas:     uint64(frame.Gas),
+		GasUsed: uint64(frame.GasUsed),
+		Input:   hexutil.Encode(frame.Input),
+		Error:   frame.Error,
+	}
+	if frame.To != nil {
+		call.To = frame.To.Hex()
+	}
+	if frame.Value != nil {
+		call.Value = frame.Value.ToInt().String()
+	}
+	if len(frame.Output) > 0 {
+		call.Output = hexutil.Encode(frame.Output)
+	}
+	if strings.HasPrefix(frame.Error, "execution reverted") {
+		call.Revert = decoder.DecodeRevert(frame.Output)
+	}
+	for i := range frame.Calls {
+		call.Calls = append(call.Calls, callFrameToModel(&frame.Calls[i]))
+	}
+	return call
+}
+
+// collectTransfers appends the ETH transfers made by the calls below frame,
+// which is at depth, in execution order. Transfers of calls that failed, or
+// that are undone because a caller failed, did not happen and are skipped.
+// Delegate calls and static calls move no ETH.
+func collectTransfers(frame *callFrame, depth int, failed bool, transfers *[]models.InternalTransfer) {
+	failed = failed || frame.Error != ""
+	if depth > 0 && !failed && frame.Value != nil && frame.Value.ToInt().Sign() > 0 &&
+		frame.Type != "DELEGATECALL" && frame.Type != "STATICCALL" {
+		transfer := models.InternalTransfer{
+			Type:  frame.Type,
+			From:  frame.From.Hex(),
+			Value: frame.Value.ToInt().String(),
+			Depth: depth,
+		}
+		if frame.To != nil {
+			transfer.To = frame.To.Hex()
+		}
+		*transfers = append(*transfers, transfer)
+	}
+	for i := range frame.Calls {
+		collectTransfers(&frame.Calls[i], depth+1, failed, transfers)
+	}
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"eth-explorer-api/internal/models"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var tracedHash = common.HexToHash("0x01")
+
+// debugAPI serves the callTracer output of tracedHash.
+type debugAPI struct{}
+
+// Error(string) "nope"
+const nopeRevert = "0x08c379a0" +
+	"0000000000000000000000000000000000000000000000000000000000000020" +
+	"0000000000000000000000000000000000000000000000000000000000000004" +
+	"6e6f706500000000000000000000000000000000000000000000000000000000"
+
+func (debugAPI) TraceTransaction(ctx context.Context, hash common.Hash, config map[string]interface{}) (json.RawMessage, error) {
+	if hash != tracedHash {
+		return nil, fmt.Errorf("transaction %s not found", hash.Hex())
+	}
+	if config["tracer"] != "callTracer" {
+		return nil, fmt.Errorf("unexpected tracer %v", config["tracer"])
+	}
+	return json.RawMessage(`{
+		"type": "CALL", "from": "0x00000000000000000000000000000000000000e0", "to": "0x00000000000000000000000000000000000000c0",
+		"value": "0x0", "gas": "0x30000", "gasUsed": "0x20000", "input": "0x12345678", "output": "0x",
+		"calls": [
+			{"type": "CALL", "from": "0x00000000000000000000000000000000000000c0", "to": "0x00000000000000000000000000000000000000a1",
+				"value": "0x1", "gas": "0x1000", "gasUsed": "0x0", "input": "0x"},
+			{"type": "DELEGATECALL", "from": "0x00000000000000000000000000000000000000c0", "to": "0x00000000000000000000000000000000000000d0",
+				"value": "0x5", "gas": "0x1000", "gasUsed": "0x10", "input": "0x"},
+			{"type": "CALL", "from": "0x00000000000000000000000000000000000000c0", "to": "0x00000000000000000000000000000000000000a2",
+				"value": "0x2", "gas": "0x1000", "gasUsed": "0x100", "input": "0x", "output": "` + nopeRevert + `",
+				"error": "execution reverted", "revertReason": "nope",
+				"calls": [{"type": "CALL", "from": "0x00000000000000000000000000000000000000a2", "to": "0x00000000000000000000000000000000000000a3",
+					"value": "0x3", "gas": "0x100", "gasUsed": "0x0", "input": "0x"}]},
+			{"type": "CREATE", "from": "0x00000000000000000000000000000000000000c0", "to": "0x00000000000000000000000000000000000000b1",
+				"value": "0x4", "gas": "0x10000", "gasUsed": "0x8000", "input": "0x6000",
+				"calls": [{"type": "SELFDESTRUCT", "from": "0x00000000000000000000000000000000000000b1", "to": "0x00000000000000000000000000000000000000a4",
+					"value": "0x4", "gas": "0x0", "gasUsed": "0x0", "input": "0x"}]}
+		]
+	}`), nil
+}
+
+// traceNode exposes a JSON-RPC client, with the debug namespace when debug
+// is set.
+type traceNode struct {
+	ChainReader
+	client *rpc.Client
+}
+
+func (n *traceNode) Client() *rpc.Client {
+	return n.client
+}
+
+func newTraceNode(t *testing.T, debug bool) *traceNode {
+	t.Helper()
+
+	server := rpc.NewServer()
+	if debug {
+		if err := server.RegisterName("debug", debugAPI{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client := rpc.DialInProc(server)
+	t.Cleanup(func() {
+		client.Close()
+		server.Stop()
+	})
+	return &traceNode{client: client}
+}
+
+func TestTraceTransaction(t *testing.T) {
+	ctx := context.Background()
+	s := NewEthServiceWithClient(newTraceNode(t, true), nil)
+
+	trace, err := s.TraceTransaction(ctx, tracedHash.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := trace.Call
+	if root.Type != "CALL" || root.GasUsed != 0x20000 || root.Input != "0x12345678" || len(root.Calls) != 4 {
+		t.Fatalf("root call = %+v", root)
+	}
+	reverted := root.Calls[2]
+	if reverted.Error != "execution reverted" || reverted.Revert == nil || reverted.Revert.Reason != "nope" || len(reverted.Calls) != 1 {
+		t.Errorf("reverted call = %+v", reverted)
+	}
+
+	// The delegate call moves no ETH and the reverted call's transfers are
+	// undone.
+	want := []models.InternalTransfer{
+		{Type: "CALL", From: common.HexToAddress("0xc0").Hex(), To: common.HexToAddress("0xa1").Hex(), Value: "1", Depth: 1},
+		{Type: "CREATE", From: common.HexToAddress("0xc0").Hex(), To: common.HexToAddress("0xb1").Hex(), Value: "4", Depth: 1},
+		{Type: "SELFDESTRUCT", From: common.HexToAddress("0xb1").Hex(), To: common.HexToAddress("0xa4").Hex(), Value: "4", Depth: 2},
+	}
+	if len(trace.InternalTransfers) != len(want) {
+		t.Fatalf("internal transfers = %+v, want %+v", trace.InternalTransfers, want)
+	}
+	for i := range want {
+		if trace.InternalTransfers[i] != want[i] {
+			t.Errorf("internal transfer %d = %+v, want %+v", i, trace.InternalTransfers[i], want[i])
+		}
+	}
+
+	if _, err := s.TraceTransaction(ctx, common.HexToHash("0x02").Hex()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("error = %v, want not found", err)
+	}
+
+	// Without the debug namespace.
+	s = NewEthServiceWithClient(newTraceNode(t, false), nil)
+	if _, err := s.TraceTransaction(ctx, tracedHash.Hex()); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("error = %v, want unsupported", err)
+	}
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"time"
+
+	"eth-explorer-api/internal/models"
+	"eth-explorer-api/internal/validation"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// transferTopic is the topic0 of ERC-20 and ERC-721 Transfer events. ERC-20
+// transfers carry the value in the data and have three topics.
+var transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// Transfer directions relative to the queried address.
+const (
+	DirectionIn   = "in"
+	DirectionOut  = "out"
+	DirectionSelf = "self"
+	DirectionAll  = "all"
+)
+
+// TokenTransferQuery holds the raw parameters of a token or NFT transfer
+// request. Token optionally restricts the result to a comma-separated list
+// of token contracts. Direction is DirectionIn, DirectionOut or DirectionAll, the
+// default. The range and pagination parameters are as in EventLogQuery.
+type TokenTransferQuery struct {
+	Address   string
+	Token     string
+	Direction string
+	FromBlock string
+	ToBlock   string
+	Limit     string
+	Cursor    string
+}
+
+// transferScan is a parsed TokenTransferQuery.
+type transferScan struct {
+	owner     common.Address
+	tokens    []common.Address
+	direction string
+	cursor    logCursor
+	limit     int
+}
+
+// parseTransferQuery validates q and resolves its block range.
+func (s *EthService) parseTransferQuery(ctx context.Context, q TokenTransferQuery) (*transferScan, error) {
+	owner, err := s.parseAddress("address", q.Address)
+	if err != nil {
+		return nil, err
+	}
+	scan := &transferScan{owner: owner}
+
+	if q.Token != "" {
+		for _, value := range strings.Split(q.Token, ",") {
+			token, err := s.parseAddress("token", strings.TrimSpace(value))
+			if err != nil {
+				return nil, err
+			}
+			scan.tokens = append(scan.tokens, token)
+		}
+	}
+
+	switch scan.direction = strings.ToLower(q.Direction); scan.direction {
+	case "":
+		scan.direction = DirectionAll
+	case DirectionAll, DirectionIn, DirectionOut:
+	default:
+		return nil, invalidInputError("invalid request", &validation.FieldError{Field: "direction", Value: q.Direction, Reason: "must be in, out or all"})
+	}
+
+	if scan.limit, err = parseLogLimit(q.Limit); err != nil {
+		return nil, err
+	}
+	if scan.cursor, err = parseLogCursor(q.Cursor); err != nil {
+		return nil, err
+	}
+	if q.Cursor == "" {
+		if scan.cursor, err = s.resolveLogRange(ctx, q.FromBlock, q.ToBlock); err != nil {
+			return nil, err
+		}
+	}
+
+	return scan, nil
+}
+
+// queries returns the log queries for transfers from the owner, whose
+// address is topic fromTopic of the event, and to the owner, at the topic
+// after it.
+func (scan *transferScan) queries(events []common.Hash, fromTopic int) []ethereum.FilterQuery {
+	ownerTopic := []common.Hash{common.BytesToHash(scan.owner.Bytes())}
+	outgoing := make([][]common.Hash, fromTopic+1)
+	incoming := make([][]common.Hash, fromTopic+2)
+	outgoing[0], incoming[0] = events, events
+	outgoing[fromTopic], incoming[fromTopic+1] = ownerTopic, ownerTopic
+
+	var queries []ethereum.FilterQuery
+	if scan.direction != DirectionIn {
+		queries = append(queries, ethereum.FilterQuery{Addresses: scan.tokens, Topics: outgoing})
+	}
+	if scan.direction != DirectionOut {
+		queries = append(queries, ethereum.FilterQuery{Addresses: scan.tokens, Topics: incoming})
+	}
+	return queries
+}
+
+// GetTokenTransfers retrieves one page of the ERC-20 transfers sent or
+// received by an address.
+func (s *EthService) GetTokenTransfers(ctx context.Context, q TokenTransferQuery) (*models.TokenTransferPage, error) {
+	scan, err := s.parseTransferQuery(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	// ERC-721 transfers share the topic but index the token ID as a fourth
+	// topic.
+	filter := logFilter{
+		queries: scan.queries([]common.Hash{transferTopic}, 1),
+		match:   func(l *types.Log) bool { return len(l.Topics) == 3 },
+	}
+	logs, next, err := s.scanLogs(ctx, filter, scan.cursor, scan.limit)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamps, err := s.blockTimestamps(ctx, logs)
+	if err != nil {
+		return nil, err
+	}
+
+	// As for token balances, metadata only labels and formats the values,
+	// so a token whose metadata cannot be read keeps its raw values.
+	tokenInfos := make(map[common.Address]tokenInfo)
+	for _, vLog := range logs {
+		if _, ok := tokenInfos[vLog.Address]; ok {
+			continue
+		}
+		tokenInfos[vLog.Address], _ = s.tokenInfo(ctx, vLog.Address)
+	}
+
+	page := &models.TokenTransferPage{
+		Transfers: []models.TokenTransfer{},
+		FromBlock: scan.cursor.fromBlock,
+		ToBlock:   scan.cursor.toBlock,
+	}
+	if next != nil {
+		page.NextCursor = next.String()
+	}
+	for _, vLog := range logs {
+		from := common.BytesToAddress(vLog.Topics[1].Bytes())
+		to := common.BytesToAddress(vLog.Topics[2].Bytes())
+		value := new(big.Int).SetBytes(vLog.Data)
+		info := tokenInfos[vLog.Address]
+
+		page.Transfers = append(page.Transfers, models.TokenTransfer{
+			TokenAddress:   vLog.Address.Hex(),
+			From:           from.Hex(),
+			To:             to.Hex(),
+			TokenSymbol:    info.symbol,
+			TokenDecimals:  info.decimals,
+			Value:          value.String(),
+			FormattedValue: info.format(value),
+			Direction:      transferDirection(scan.owner, from, to),
+			BlockNumber:    vLog.BlockNumber,
+			BlockHash:      vLog.BlockHash.Hex(),
+			Timestamp:      timestamps[vLog.BlockNumber],
+			TxHash:         vLog.TxHash.Hex(),
+			LogIndex:       vLog.Index,
+		})
+	}
+
+	return page, nil
+}
+
+func transferDirection(addr, from, to common.Address) string {
+	switch {
+	case from == addr && to == addr:
+		return DirectionSelf
+	case from == addr:
+		return DirectionOut
+	default:
+		return DirectionIn
+	}
+}
+
+// blockTimestamps returns the timestamps of the blocks the logs are in,
+// keyed by block number. The logs are in chain order, so each block is
+// fetched once.
+func (s *EthService) blockTimestamps(ctx context.Context, logs []types.Log) (map[uint64]time.Time, error) {
+	var numbers []uint64
+	for _, vLog := range logs {
+		if len(numbers) == 0 || numbers[len(numbers)-1] != vLog.BlockNumber {
+			numbers = append(numbers, vLog.BlockNumber)
+		}
+	}
+
+	headers, err := s.blockHeaders(ctx, numbers)
+	if err != nil {
+		return nil, upstreamError("failed to fetch block headers", err)
+	}
+	timestamps := make(map[uint64]time.Time, len(numbers))
+	for i, number := range numbers {
+		timestamps[number] = time.Unix(int64(headers[i].Timestamp), 0)
+	}
+	return timestamps, nil
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// brokenTokenNode serves transfer logs of a token whose metadata calls
+// fail.
+type brokenTokenNode struct {
+	logNode
+}
+
+func (n *brokenTokenNode) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return nil, errors.New("connection reset")
+}
+
+func TestGetTokenTransfersWithoutMetadata(t *testing.T) {
+	node := &brokenTokenNode{logNode{head: 100, maxRange: 1000}}
+	node.logs = []types.Log{{
+		Address:     heldToken,
+		Topics:      []common.Hash{transferTopic, common.BytesToHash(testAddr.Bytes()), common.BytesToHash(testTo.Bytes())},
+		Data:        common.LeftPadBytes(big.NewInt(1500000).Bytes(), 32),
+		BlockNumber: 50,
+	}}
+	s := NewEthServiceWithClient(node, nil)
+
+	page, err := s.GetTokenTransfers(context.Background(), TokenTransferQuery{Address: testTo.Hex(), FromBlock: "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Transfers) != 1 {
+		t.Fatalf("transfers = %+v, want one", page.Transfers)
+	}
+	transfer := page.Transfers[0]
+	if transfer.Value != "1500000" || transfer.FormattedValue != "" || transfer.TokenSymbol != "" {
+		t.Errorf("transfer = %+v, want the raw value only", transfer)
+	}
+}
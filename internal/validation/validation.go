@@ -0,0 +1,110 @@
+// Package validation parses and validates user-supplied Ethereum identifiers.
+package validation
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// FieldError reports a malformed request field.
+type FieldError struct {
+	Field  string
+	Value  string
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s %q %s", e.Field, e.Value, e.Reason)
+}
+
+// Address parses a 0x-prefixed 20-byte hex address. Mixed-case input must be
+// a valid EIP-55 checksum; when strictChecksum is set, single-case input is
+// rejected as well.
+func Address(field, value string, strictChecksum bool) (common.Address, error) {
+	digits, ok := hexDigits(value, common.AddressLength)
+	if !ok {
+		return common.Address{}, &FieldError{Field: field, Value: value, Reason: "is not a 0x-prefixed 20-byte hex address"}
+	}
+
+	addr := common.HexToAddress(value)
+	mixedCase := strings.ToLower(digits) != digits && strings.ToUpper(digits) != digits
+	if (mixedCase || strictChecksum) && addr.Hex() != "0x"+digits {
+		return common.Address{}, &FieldError{Field: field, Value: value, Reason: "does not match its EIP-55 checksum"}
+	}
+
+	return addr, nil
+}
+
+// Hash parses a 0x-prefixed 32-byte hex hash.
+func Hash(field, value string) (common.Hash, error) {
+	if _, ok := hexDigits(value, common.HashLength); !ok {
+		return common.Hash{}, &FieldError{Field: field, Value: value, Reason: "is not a 0x-prefixed 32-byte hex hash"}
+	}
+
+	return common.HexToHash(value), nil
+}
+
+// BlockNumber parses a non-negative decimal or 0x-prefixed hex block number
+// that fits in an int64, the range accepted by JSON-RPC nodes.
+func BlockNumber(field, value string) (*big.Int, error) {
+	num, ok := parseInteger(value)
+	if !ok {
+		return nil, &FieldError{Field: field, Value: value, Reason: "is not a decimal or 0x-prefixed hex block number"}
+	}
+	if num.Sign() < 0 {
+		return nil, &FieldError{Field: field, Value: value, Reason: "must not be negative"}
+	}
+	if !num.IsInt64() {
+		return nil, &FieldError{Field: field, Value: value, Reason: "is too large"}
+	}
+
+	return num, nil
+}
+
+// Uint256 parses a non-negative decimal or 0x-prefixed hex integer of at
+// most 256 bits, such as an ERC-721 or ERC-1155 token ID.
+func Uint256(field, value string) (*big.Int, error) {
+	num, ok := parseInteger(value)
+	if !ok {
+		return nil, &FieldError{Field: field, Value: value, Reason: "is not a decimal or 0x-prefixed hex integer"}
+	}
+	if num.Sign() < 0 {
+		return nil, &FieldError{Field: field, Value: value, Reason: "must not be negative"}
+	}
+	if num.BitLen() > 256 {
+		return nil, &FieldError{Field: field, Value: value, Reason: "does not fit in 256 bits"}
+	}
+
+	return num, nil
+}
+
+// parseInteger parses a decimal or 0x-prefixed hex integer without a
+// leading plus sign.
+func parseInteger(value string) (*big.Int, bool) {
+	num, ok := new(big.Int), false
+	if digits, found := strings.CutPrefix(value, "0x"); found {
+		if digits != "" && !strings.HasPrefix(digits, "+") && !strings.HasPrefix(digits, "-") {
+			_, ok = num.SetString(digits, 16)
+		}
+	} else if value != "" && !strings.HasPrefix(value, "+") {
+		_, ok = num.SetString(value, 10)
+	}
+	return num, ok
+}
+
+// hexDigits returns the hex digits of a 0x-prefixed string encoding exactly
+// size bytes.
+func hexDigits(value string, size int) (string, bool) {
+	digits, found := strings.CutPrefix(value, "0x")
+	if !found || len(digits) != 2*size {
+		return "", false
+	}
+	if _, err := hex.DecodeString(digits); err != nil {
+		return "", false
+	}
+	return digits, true
+}
@@ -0,0 +1,100 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAddress(t *testing.T) {
+	const checksummed = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+
+	tests := []struct {
+		value   string
+		strict  bool
+		wantErr bool
+	}{
+		{checksummed, false, false},
+		{checksummed, true, false},
+		{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", false, false},
+		{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", true, true},
+		{"0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED", false, false},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", false, true},
+		{"5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", false, true},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA", false, true},
+		{"0xzzAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", false, true},
+		{"foo", false, true},
+		{"", false, true},
+	}
+
+	for _, tt := range tests {
+		addr, err := Address("address", tt.value, tt.strict)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Address(%q, strict=%t) error = %v, wantErr %t", tt.value, tt.strict, err, tt.wantErr)
+			continue
+		}
+		if err == nil && addr.Hex() != checksummed {
+			t.Errorf("Address(%q) = %s, want %s", tt.value, addr.Hex(), checksummed)
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	valid := "0x88df016429689c079f3b2f6ad39fa052532c56795b733da78a91ebe6a713944b"
+	if _, err := Hash("hash", valid); err != nil {
+		t.Errorf("Hash(%q) error = %v", valid, err)
+	}
+
+	for _, value := range []string{"", "0x", "0x1234", valid[2:], valid + "00", "0x" + valid[4:] + "zz"} {
+		if _, err := Hash("hash", value); err == nil {
+			t.Errorf("Hash(%q) succeeded, want error", value)
+		}
+	}
+}
+
+func TestBlockNumber(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int64
+	}{
+		{"0", 0},
+		{"18500000", 18500000},
+		{"0x11a49a0", 18500000},
+		{"9223372036854775807", 9223372036854775807},
+	}
+	for _, tt := range tests {
+		got, err := BlockNumber("number", tt.value)
+		if err != nil {
+			t.Errorf("BlockNumber(%q) error = %v", tt.value, err)
+			continue
+		}
+		if got.Int64() != tt.want {
+			t.Errorf("BlockNumber(%q) = %s, want %d", tt.value, got, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "a", "x", "0x", "0x-1", "-1", "+1", "1.5", "9223372036854775808", "0x8000000000000000"} {
+		_, err := BlockNumber("number", value)
+		var fieldErr *FieldError
+		if !errors.As(err, &fieldErr) || fieldErr.Field != "number" {
+			t.Errorf("BlockNumber(%q) error = %v, want FieldError for number", value, err)
+		}
+	}
+}
+
+func TestUint256(t *testing.T) {
+	maxUint256 := "0x" + strings.Repeat("f", 64)
+	for _, value := range []string{"0", "1", "0x2a", maxUint256} {
+		if _, err := Uint256("tokenId", value); err != nil {
+			t.Errorf("Uint256(%q) error = %v", value, err)
+		}
+	}
+
+	for _, value := range []string{"", "x", "-1", "+1", "0x1" + strings.Repeat("0", 64)} {
+		_, err := Uint256("tokenId", value)
+		var fieldErr *FieldError
+		if !errors.As(err, &fieldErr) || fieldErr.Field != "tokenId" {
+			t.Errorf("Uint256(%q) error = %v, want FieldError for tokenId", value, err)
+		}
+	}
+}